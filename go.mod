@@ -11,6 +11,7 @@ require (
 	github.com/hashicorp/terraform-plugin-mux v0.23.1
 	github.com/previder/previder-go-sdk v1.5.2
 	go.mongodb.org/mongo-driver/v2 v2.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
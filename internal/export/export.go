@@ -0,0 +1,181 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"github.com/previder/terraform-provider-previder/previder"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const Command = "export"
+
+// Run walks over all list resources of the provider and writes the configuration and import blocks of every
+// object to one file per resource type in the output directory.
+func Run(ctx context.Context, args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	customer := flags.String("customer", "", "ObjectId of the (sub) customer to export")
+	out := flags.String("out", ".", "Directory to write the generated configuration to")
+	token := flags.String("token", "", "API token, defaults to PREVIDER_TOKEN")
+	url := flags.String("url", "", "API endpoint URL, defaults to PREVIDER_URL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *customer != "" && !util.IsValidObjectId(*customer) {
+		return fmt.Errorf("customer %q is not a valid ObjectId", *customer)
+	}
+
+	config := previder.Config{
+		Token:      types.StringValue(*token),
+		Url:        types.StringValue(*url),
+		CustomerId: types.StringValue(*customer),
+	}
+	baseClient, err := config.Client()
+	if err != nil {
+		return err
+	}
+	providerData := &util.ProviderData{Client: baseClient, CustomerId: *customer}
+
+	p := previder.NewPreviderProvider()
+	listProvider, ok := p.(provider.ProviderWithListResources)
+	if !ok {
+		return errors.New("provider does not implement list resources")
+	}
+
+	resources := make(map[string]resource.Resource)
+	for _, newResource := range p.Resources(ctx) {
+		r := newResource()
+		metadataResp := resource.MetadataResponse{}
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "previder"}, &metadataResp)
+		resources[metadataResp.TypeName] = r
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	for _, newListResource := range listProvider.ListResources(ctx) {
+		listResource := newListResource()
+		metadataResp := resource.MetadataResponse{}
+		listResource.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "previder"}, &metadataResp)
+		resourceType := metadataResp.TypeName
+
+		r, ok := resources[resourceType]
+		if !ok {
+			return fmt.Errorf("no resource found for list resource %s", resourceType)
+		}
+
+		content, count, err := exportResourceType(ctx, providerData, resourceType, listResource, r, stderr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", resourceType, err)
+		}
+		_, _ = fmt.Fprintf(stderr, "Exported %d %s\n", count, resourceType)
+		if count == 0 {
+			continue
+		}
+
+		err = os.WriteFile(filepath.Join(*out, resourceType+".tf"), []byte(content), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportResourceType(ctx context.Context, providerData *util.ProviderData, resourceType string, listResource list.ListResource, r resource.Resource, stderr io.Writer) (string, int, error) {
+	if configurable, ok := listResource.(list.ListResourceWithConfigure); ok {
+		configureResp := resource.ConfigureResponse{}
+		configurable.Configure(ctx, resource.ConfigureRequest{ProviderData: providerData}, &configureResp)
+		if configureResp.Diagnostics.HasError() {
+			return "", 0, diagnosticsError(configureResp.Diagnostics)
+		}
+	}
+
+	schemaResp := resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	identitySchemaResp := resource.IdentitySchemaResponse{}
+	if withIdentity, ok := r.(resource.ResourceWithIdentity); ok {
+		withIdentity.IdentitySchema(ctx, resource.IdentitySchemaRequest{}, &identitySchemaResp)
+	}
+
+	listSchemaResp := list.ListResourceSchemaResponse{}
+	listResource.ListResourceConfigSchema(ctx, list.ListResourceSchemaRequest{}, &listSchemaResp)
+
+	// All filters are left empty, so every object is exported
+	configType := listSchemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	configValues := make(map[string]tftypes.Value)
+	for k, t := range configType.AttributeTypes {
+		configValues[k] = tftypes.NewValue(t, nil)
+	}
+
+	req := list.ListRequest{
+		Config: tfsdk.Config{
+			Raw:    tftypes.NewValue(configType, configValues),
+			Schema: listSchemaResp.Schema,
+		},
+		IncludeResource:        true,
+		ResourceSchema:         schemaResp.Schema,
+		ResourceIdentitySchema: identitySchemaResp.IdentitySchema,
+	}
+	stream := list.ListResultsStream{}
+	listResource.List(ctx, req, &stream)
+	if stream.Results == nil {
+		return "", 0, nil
+	}
+
+	attributes := schemaAttributes(schemaResp.Schema.Attributes)
+	usedLabels := make(map[string]bool)
+
+	var b strings.Builder
+	var count int
+	for result := range stream.Results {
+		for _, d := range result.Diagnostics {
+			_, _ = fmt.Fprintf(stderr, "%s: %s: %s\n", d.Severity(), d.Summary(), d.Detail())
+		}
+		if result.Diagnostics.HasError() {
+			if result.Identity == nil {
+				return "", 0, diagnosticsError(result.Diagnostics)
+			}
+			continue
+		}
+
+		var identity util.ResourceIdentity
+		diags := result.Identity.Get(ctx, &identity)
+		if diags.HasError() {
+			return "", 0, diagnosticsError(diags)
+		}
+
+		label := resourceLabel(result.DisplayName, usedLabels)
+		writeImportBlock(&b, resourceType, label, identity.Id.ValueString())
+		err := writeResourceBlock(&b, resourceType, label, attributes, result.Resource.Raw)
+		if err != nil {
+			return "", 0, err
+		}
+		count++
+	}
+
+	return b.String(), count, nil
+}
+
+func diagnosticsError(diags diag.Diagnostics) error {
+	var errs []error
+	for _, d := range diags.Errors() {
+		errs = append(errs, fmt.Errorf("%s: %s", d.Summary(), d.Detail()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,274 @@
+package export
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// attributeSchema is the part of a schema attribute needed to decide whether an attribute belongs in the configuration
+type attributeSchema interface {
+	IsComputed() bool
+	IsOptional() bool
+	IsRequired() bool
+}
+
+var invalidLabelCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func schemaAttributes(in map[string]schema.Attribute) map[string]attributeSchema {
+	attributes := make(map[string]attributeSchema)
+	for k, v := range in {
+		attributes[k] = v
+	}
+	return attributes
+}
+
+// nestedAttributes returns the attributes of a nested attribute, or nil when the attribute is not nested
+func nestedAttributes(in attributeSchema) map[string]attributeSchema {
+	nested, ok := in.(schema.NestedAttribute)
+	if !ok {
+		return nil
+	}
+	attributes := make(map[string]attributeSchema)
+	for k, v := range nested.GetNestedObject().GetAttributes() {
+		attributes[k] = v
+	}
+	return attributes
+}
+
+// resourceLabel turns the name of an object into a valid and unique Terraform resource label
+func resourceLabel(name string, used map[string]bool) string {
+	label := strings.Trim(invalidLabelCharacters.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if label == "" || (label[0] >= '0' && label[0] <= '9') || label[0] == '-' {
+		label = "r_" + label
+	}
+
+	unique := label
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+	used[unique] = true
+
+	return unique
+}
+
+func writeImportBlock(b *strings.Builder, resourceType string, label string, id string) {
+	b.WriteString("import {\n")
+	b.WriteString(fmt.Sprintf("  to = %s.%s\n", resourceType, label))
+	b.WriteString(fmt.Sprintf("  id = %s\n", quote(id)))
+	b.WriteString("}\n\n")
+}
+
+func writeResourceBlock(b *strings.Builder, resourceType string, label string, attributes map[string]attributeSchema, value tftypes.Value) error {
+	b.WriteString(fmt.Sprintf("resource %s %s {\n", quote(resourceType), quote(label)))
+	err := writeAttributes(b, 1, attributes, value)
+	if err != nil {
+		return err
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
+
+// writeAttributes writes all configurable attributes of an object value, computed only attributes are skipped
+func writeAttributes(b *strings.Builder, indent int, attributes map[string]attributeSchema, value tftypes.Value) error {
+	var values map[string]tftypes.Value
+	if err := value.As(&values); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	prefix := strings.Repeat("  ", indent)
+	for _, k := range keys {
+		attribute, ok := attributes[k]
+		if !ok || !(attribute.IsRequired() || attribute.IsOptional()) {
+			continue
+		}
+		v := values[k]
+		if !v.IsKnown() || v.IsNull() {
+			continue
+		}
+		if !attribute.IsRequired() && isEmptyString(v) {
+			continue
+		}
+
+		nested := nestedAttributes(attribute)
+		if nested == nil {
+			rendered, err := renderValue(v, indent)
+			if err != nil {
+				return fmt.Errorf("attribute %s: %w", k, err)
+			}
+			b.WriteString(fmt.Sprintf("%s%s = %s\n", prefix, k, rendered))
+			continue
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s = ", prefix, k))
+		if err := writeNestedValue(b, indent, nested, v); err != nil {
+			return fmt.Errorf("attribute %s: %w", k, err)
+		}
+		b.WriteString("\n")
+	}
+
+	return nil
+}
+
+func writeNestedValue(b *strings.Builder, indent int, attributes map[string]attributeSchema, value tftypes.Value) error {
+	prefix := strings.Repeat("  ", indent)
+
+	switch {
+	case value.Type().Is(tftypes.Map{}):
+		var elements map[string]tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(elements))
+		for k := range elements {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{\n")
+		for _, k := range keys {
+			b.WriteString(fmt.Sprintf("%s  %s = {\n", prefix, quote(k)))
+			if err := writeAttributes(b, indent+2, attributes, elements[k]); err != nil {
+				return err
+			}
+			b.WriteString(fmt.Sprintf("%s  }\n", prefix))
+		}
+		b.WriteString(prefix + "}")
+	case value.Type().Is(tftypes.List{}) || value.Type().Is(tftypes.Set{}):
+		var elements []tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return err
+		}
+		b.WriteString("[\n")
+		for _, element := range elements {
+			b.WriteString(prefix + "  {\n")
+			if err := writeAttributes(b, indent+2, attributes, element); err != nil {
+				return err
+			}
+			b.WriteString(prefix + "  },\n")
+		}
+		b.WriteString(prefix + "]")
+	default:
+		b.WriteString("{\n")
+		if err := writeAttributes(b, indent+1, attributes, value); err != nil {
+			return err
+		}
+		b.WriteString(prefix + "}")
+	}
+
+	return nil
+}
+
+func renderValue(value tftypes.Value, indent int) (string, error) {
+	if value.IsNull() {
+		return "null", nil
+	}
+
+	switch {
+	case value.Type().Is(tftypes.String):
+		var s string
+		if err := value.As(&s); err != nil {
+			return "", err
+		}
+		return quote(s), nil
+	case value.Type().Is(tftypes.Bool):
+		var v bool
+		if err := value.As(&v); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%t", v), nil
+	case value.Type().Is(tftypes.Number):
+		var v big.Float
+		if err := value.As(&v); err != nil {
+			return "", err
+		}
+		return v.Text('f', -1), nil
+	case value.Type().Is(tftypes.List{}) || value.Type().Is(tftypes.Set{}) || value.Type().Is(tftypes.Tuple{}):
+		var elements []tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return "", err
+		}
+		var rendered []string
+		for _, element := range elements {
+			r, err := renderValue(element, indent)
+			if err != nil {
+				return "", err
+			}
+			rendered = append(rendered, r)
+		}
+		return "[" + strings.Join(rendered, ", ") + "]", nil
+	case value.Type().Is(tftypes.Map{}) || value.Type().Is(tftypes.Object{}):
+		var elements map[string]tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return "", err
+		}
+		keys := make([]string, 0, len(elements))
+		for k := range elements {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		prefix := strings.Repeat("  ", indent)
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, k := range keys {
+			r, err := renderValue(elements[k], indent+1)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(fmt.Sprintf("%s  %s = %s\n", prefix, quote(k), r))
+		}
+		b.WriteString(prefix + "}")
+		return b.String(), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", value.Type())
+}
+
+func isEmptyString(value tftypes.Value) bool {
+	if !value.Type().Is(tftypes.String) {
+		return false
+	}
+	var s string
+	_ = value.As(&s)
+	return s == ""
+}
+
+// quote returns a HCL string literal, template sequences are escaped so they are not interpreted
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteString(fmt.Sprintf(`\u%04x`, r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"flag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "web01", want: `"web01"`},
+		{name: "empty", in: "", want: `""`},
+		{name: "quotes", in: `say "hi"`, want: `"say \"hi\""`},
+		{name: "backslash", in: `C:\temp`, want: `"C:\\temp"`},
+		{name: "newlines", in: "line1\nline2\r\n", want: `"line1\nline2\r\n"`},
+		{name: "tab", in: "a\tb", want: `"a\tb"`},
+		{name: "interpolation", in: "${var.name}", want: `"$${var.name}"`},
+		{name: "directive", in: "%{if true}", want: `"%%{if true}"`},
+		{name: "dollar without brace", in: "costs $5", want: `"costs $5"`},
+		{name: "dollar at end", in: "a$", want: `"a$"`},
+		{name: "control character", in: "a\x01b", want: `"a\u0001b"`},
+		{name: "unicode", in: "café", want: `"café"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quote(tt.in); got != tt.want {
+				t.Errorf("quote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{name: "lower case", in: []string{"Web01"}, want: []string{"web01"}},
+		{name: "spaces and dots", in: []string{"web 01.example.com"}, want: []string{"web_01_example_com"}},
+		{name: "dashes kept", in: []string{"db-primary"}, want: []string{"db-primary"}},
+		{name: "trimmed", in: []string{"  web  "}, want: []string{"web"}},
+		{name: "leading digit", in: []string{"01web"}, want: []string{"r_01web"}},
+		{name: "leading dash", in: []string{"-web"}, want: []string{"r_-web"}},
+		{name: "no valid characters", in: []string{"ëëë"}, want: []string{"r_"}},
+		{name: "empty", in: []string{""}, want: []string{"r_"}},
+		{name: "duplicates", in: []string{"web", "Web", "web!"}, want: []string{"web", "web_2", "web_3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := make(map[string]bool)
+			for i, in := range tt.in {
+				if got := resourceLabel(in, used); got != tt.want[i] {
+					t.Errorf("resourceLabel(%q) = %s, want %s", in, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBlocks(t *testing.T) {
+	attributes := schemaAttributes(map[string]schema.Attribute{
+		"id":          schema.StringAttribute{Computed: true},
+		"name":        schema.StringAttribute{Required: true},
+		"description": schema.StringAttribute{Optional: true},
+		"user_data":   schema.StringAttribute{Optional: true},
+		"group":       schema.StringAttribute{Optional: true, Computed: true},
+		"memory":      schema.Int64Attribute{Required: true},
+		"protected":   schema.BoolAttribute{Optional: true},
+		"tags":        schema.SetAttribute{ElementType: types.StringType, Optional: true},
+		"disks": schema.MapNestedAttribute{
+			Required: true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"id":   schema.StringAttribute{Computed: true},
+					"size": schema.Int64Attribute{Required: true},
+				},
+			},
+		},
+	})
+
+	diskType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"id": tftypes.String, "size": tftypes.Number}}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"id":          tftypes.String,
+		"name":        tftypes.String,
+		"description": tftypes.String,
+		"user_data":   tftypes.String,
+		"group":       tftypes.String,
+		"memory":      tftypes.Number,
+		"protected":   tftypes.Bool,
+		"tags":        tftypes.Set{ElementType: tftypes.String},
+		"disks":       tftypes.Map{ElementType: diskType},
+	}}
+	value := tftypes.NewValue(objectType, map[string]tftypes.Value{
+		"id":          tftypes.NewValue(tftypes.String, "5f4e3d2c1b0a998877665544"),
+		"name":        tftypes.NewValue(tftypes.String, `web "01"`),
+		"description": tftypes.NewValue(tftypes.String, ""),
+		"user_data":   tftypes.NewValue(tftypes.String, "#cloud-config\nhostname: ${hostname}\n"),
+		"group":       tftypes.NewValue(tftypes.String, nil),
+		"memory":      tftypes.NewValue(tftypes.Number, big.NewFloat(4096)),
+		"protected":   tftypes.NewValue(tftypes.Bool, true),
+		"tags": tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "app"),
+			tftypes.NewValue(tftypes.String, "prod"),
+		}),
+		"disks": tftypes.NewValue(tftypes.Map{ElementType: diskType}, map[string]tftypes.Value{
+			"os disk": tftypes.NewValue(diskType, map[string]tftypes.Value{
+				"id":   tftypes.NewValue(tftypes.String, "disk-1"),
+				"size": tftypes.NewValue(tftypes.Number, big.NewFloat(20480)),
+			}),
+		}),
+	})
+
+	var b strings.Builder
+	label := resourceLabel(`web "01"`, make(map[string]bool))
+	writeImportBlock(&b, "previder_virtual_server", label, "5f4e3d2c1b0a998877665544")
+	if err := writeResourceBlock(&b, "previder_virtual_server", label, attributes, value); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "blocks.tf.golden", b.String())
+}
+
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match %s, run go test with -update to accept it\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package kubernetes_cluster
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+var _ list.ListResource = (*listResourceImpl)(nil)
+var _ list.ListResourceWithConfigure = (*listResourceImpl)(nil)
+
+type listResourceImpl struct {
+	client *client.PreviderClient
+}
+
+type listConfigData struct {
+	Query   types.String `tfsdk:"query"`
+	Name    types.String `tfsdk:"name"`
+	State   types.String `tfsdk:"state"`
+	Version types.String `tfsdk:"version"`
+}
+
+func NewListResource() list.ListResource {
+	return &listResourceImpl{}
+}
+
+func (r *listResourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func (r *listResourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, _, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *listResourceImpl) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Optional:    true,
+				Description: "Search query passed to the Previder API",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list Kubernetes clusters with exactly this name",
+			},
+			"state": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list Kubernetes clusters in this state, e.g. READY",
+			},
+			"version": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list Kubernetes clusters running this version",
+			},
+		},
+	}
+}
+
+func (r *listResourceImpl) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	util.ListResults(ctx, req, stream, func(config listConfigData) util.ListObjects[client.KubernetesCluster] {
+		return util.ListObjects[client.KubernetesCluster]{
+			ObjectType: "Kubernetes Clusters",
+			Page:       r.client.KubernetesCluster.Page,
+			Query:      config.Query,
+			Id:         func(cluster client.KubernetesCluster) string { return cluster.Id },
+			Name:       func(cluster client.KubernetesCluster) string { return cluster.Name },
+			Matches: func(cluster client.KubernetesCluster) bool {
+				return util.MatchesFilter(config.Name, cluster.Name) &&
+					util.MatchesFilter(config.State, cluster.State) &&
+					util.MatchesFilter(config.Version, cluster.Version)
+			},
+			Resource: func(id string) (any, diag.Diagnostics) {
+				var data resourceData
+				var diags diag.Diagnostics
+				kubernetesCluster, err := r.client.KubernetesCluster.Get(id)
+				if err != nil {
+					diags.AddError("Error while listing Kubernetes Clusters", fmt.Sprintf("Kubernetes Cluster %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				diags.Append(populateResourceData(r.client, &data, kubernetesCluster, nil)...)
+				return &data, diags
+			},
+		}
+	})
+}
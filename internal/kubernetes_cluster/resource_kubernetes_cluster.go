@@ -14,22 +14,29 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
-	"log"
 	"reflect"
 	"strings"
 	"time"
 )
 
 const ResourceType = "previder_kubernetes_cluster"
+const logSubsystem = "previder.kubernetes_cluster"
+
+var stateUpgrades []util.StateUpgrade
 
 var _ resource.Resource = (*resourceImpl)(nil)
 var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
 var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithIdentity = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
 
 type resourceImpl struct {
-	client *client.PreviderClient
+	client         *client.PreviderClient
+	customer       string
+	customerClient util.CustomerClient
 }
 
 func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -40,17 +47,18 @@ func NewResource() resource.Resource {
 	return &resourceImpl{}
 }
 
-func (r *resourceImpl) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	var newDiags diag.Diagnostics
-	r.client, newDiags = util.ConfigureClient(req.ProviderData)
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
 	resp.Diagnostics.Append(newDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.customerClient = util.ConfigureCustomerClient(req.ProviderData)
 }
 
 func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
-
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
 	resp.Schema.Attributes = map[string]schema.Attribute{
 		"id": schema.StringAttribute{
 			MarkdownDescription: "ID of the Kubernetes Cluster",
@@ -160,7 +168,16 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 	}
 }
 
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = util.IdentitySchema()
+}
+
 func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, data resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -177,12 +194,25 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 		}
 	}
 
+	pending, diags := util.GetPendingCreate(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if pending != nil {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Checking interrupted Kubernetes cluster create", map[string]any{util.LogFieldId: pending.Id, util.LogFieldState: cluster.State})
+		if cluster.State == "READY" {
+			resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
+		} else {
+			resp.Diagnostics.AddWarning("Kubernetes cluster not ready", fmt.Sprintf("Kubernetes Cluster (%s) is still being created (%s), it is checked again on the next refresh", pending.Id, cluster.State))
+		}
+	}
+
 	populateResourceData(r.client, &data, cluster, &state)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var plan, data resourceData
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
@@ -239,18 +269,31 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	err = waitForKubernetesClusterState(r.client, plan.Id, "READY")
+	// The cluster exists from now on, an interrupted create is checked by Read
+	resp.Diagnostics.Append(util.SetPendingCreate(ctx, resp.Private, util.PendingCreate{Id: plan.Id.ValueString()})...)
+
+	err = waitForKubernetesClusterState(ctx, r.client, plan.Id, "READY")
 	if err != nil {
-		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error waiting for Kubernetes Cluster (%s) to become ready: %s", data.Id, err))
+		resp.Diagnostics.Append(util.PendingCreateDiagnostics(ctx, "Kubernetes Cluster", plan.Id.ValueString(), err)...)
+		// The cluster may have changed while waiting
+		if pendingCluster, err := r.client.KubernetesCluster.Get(plan.Id.ValueString()); err == nil {
+			createdCluster = pendingCluster
+		}
+		populateResourceData(r.client, &data, createdCluster, &plan)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 		return
 	}
+	resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
 
 	populateResourceData(r.client, &data, createdCluster, &plan)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, plan, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -285,7 +328,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 	update.ComputeCluster = plan.ComputeCluster.ValueString()
 	update.HighAvailableControlPlane = plan.HighAvailableControlPlane.ValueBool()
 
-	log.Printf("Updating cluster %s", state.Id.ValueString())
+	tflog.SubsystemInfo(ctx, logSubsystem, "Updating Kubernetes cluster", map[string]any{util.LogFieldId: state.Id.ValueString()})
 	err = r.client.KubernetesCluster.Update(state.Id.ValueString(), update)
 
 	if err != nil {
@@ -293,7 +336,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	err = waitForKubernetesClusterState(r.client, state.Id, "READY")
+	err = waitForKubernetesClusterState(ctx, r.client, state.Id, "READY")
 	if err != nil {
 		resp.Diagnostics.AddError("Error while waiting for cluster to become ready", err.Error())
 		return
@@ -309,10 +352,12 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 	populateResourceData(r.client, &data, updatedCluster, &plan)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 
 }
 
 func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -320,43 +365,82 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	log.Printf("[INFO] Deleting Kubernetes Cluster: %s", data.Id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Deleting Kubernetes cluster", map[string]any{util.LogFieldId: data.Id.ValueString()})
 
 	err := r.client.KubernetesCluster.Delete(data.Id.ValueString())
 
-	err = waitForKubernetesClusterDeleted(r.client, data.Id)
+	err = waitForKubernetesClusterDeleted(ctx, r.client, data.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error deleting Kubernetes Cluster: %s", err.Error())
 	}
 }
 
 func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
-	var cluster, _ = r.client.KubernetesCluster.Get(req.ID)
+	importId, newDiags := util.ImportIdFromRequest(ctx, req)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	populateResourceData(r.client, &data, cluster, nil)
+	id, customerId, newDiags := util.ResolveImportId(importId, r.listImportCandidates)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	importClient, newDiags := util.ImportClient(r.client, r.customer, r.customerClient, customerId)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cluster, err := importClient.KubernetesCluster.Get(id)
+	if err != nil {
+		resp.Diagnostics.AddError("Kubernetes Cluster not found", fmt.Sprintf("Error while importing Kubernetes Cluster (%s): %s", id, err))
+		return
+	}
+
+	populateResourceData(importClient, &data, cluster, nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
+
+}
 
+// Kubernetes clusters are not part of a group, so group/<group>/<name> imports never match
+func (r *resourceImpl) listImportCandidates(query string) ([]util.ImportCandidate, error) {
+	clusters, err := util.PageAll(r.client.KubernetesCluster.Page, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []util.ImportCandidate
+	for _, cluster := range clusters {
+		candidates = append(candidates, util.ImportCandidate{Id: cluster.Id, Name: cluster.Name})
+	}
+	return candidates, nil
 }
 
-func waitForKubernetesClusterState(client *client.PreviderClient, id types.String, target string) error {
-	log.Printf("[INFO] Waiting for Kubernetes cluster (%s) to have state %s", id, target)
+func waitForKubernetesClusterState(ctx context.Context, client *client.PreviderClient, id types.String, target string) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for Kubernetes cluster to have state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldTarget: target})
 
 	backoffOperation := func() error {
 		cluster, err := client.KubernetesCluster.Get(id.ValueString())
 
 		if err != nil {
-			log.Printf("invalid Kubernetes Cluster id: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Kubernetes cluster could not be fetched while waiting for state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid kubernetes cluster id: %s", id))
 		}
+		tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for Kubernetes cluster to become ready", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldState: cluster.State, util.LogFieldTarget: target})
 		if cluster.State != target {
 			return errors.New(fmt.Sprintf("Waiting for cluster to become ready: %s (%s)", id, cluster.State))
 		}
 		return nil
 	}
 	// Max waiting time is 30 mins (should be max 10 mins for smaller clusters)
-	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 180)
+	backoffConfig := backoff.WithContext(backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 180), ctx)
 
 	err := backoff.Retry(backoffOperation, backoffConfig)
 	if err != nil {
@@ -366,27 +450,25 @@ func waitForKubernetesClusterState(client *client.PreviderClient, id types.Strin
 	return nil
 }
 
-func waitForKubernetesClusterDeleted(client *client.PreviderClient, id types.String) error {
+func waitForKubernetesClusterDeleted(ctx context.Context, client *client.PreviderClient, id types.String) error {
 
 	backoffOperation := func() error {
 		cluster, err := client.KubernetesCluster.Get(id.ValueString())
-		log.Printf("Fetching cluster: %v", id)
 		if err != nil {
-			log.Printf("Error while fetching a cluster")
 			if strings.Contains(err.Error(), "404") &&
 				strings.Contains(err.Error(), "not found") {
 				return nil
 			}
-			log.Printf("Cluster still exists, but got an error: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Kubernetes cluster still exists, but got an error", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("cluster still exists, but got an error: %s", id.ValueString()))
 		}
 		if cluster.State == "PENDING_REMOVAL" {
-			log.Printf("Waiting for cluster to be gone: %v", cluster.State)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for Kubernetes cluster to be gone", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldState: cluster.State})
 			return errors.New(fmt.Sprintf("Waiting for cluster to be gone: %s", id.ValueString()))
 		}
 		return nil
 	}
-	log.Printf("Waiting for cluster deletion: %v", id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for Kubernetes cluster deletion", map[string]any{util.LogFieldId: id.ValueString()})
 	// Max waiting time is 20 mins
 	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 120)
 
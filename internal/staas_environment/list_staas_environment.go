@@ -0,0 +1,95 @@
+package staas_environment
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+var _ list.ListResource = (*listResourceImpl)(nil)
+var _ list.ListResourceWithConfigure = (*listResourceImpl)(nil)
+
+type listResourceImpl struct {
+	client *client.PreviderClient
+}
+
+type listConfigData struct {
+	Query   types.String `tfsdk:"query"`
+	Name    types.String `tfsdk:"name"`
+	Cluster types.String `tfsdk:"cluster"`
+	Type    types.String `tfsdk:"type"`
+}
+
+func NewListResource() list.ListResource {
+	return &listResourceImpl{}
+}
+
+func (r *listResourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func (r *listResourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, _, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *listResourceImpl) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Optional:    true,
+				Description: "Search query passed to the Previder API",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list STaaS environments with exactly this name",
+			},
+			"cluster": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list STaaS environments on this cluster, by ObjectId or name",
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list STaaS environments of this type, e.g. NFS",
+			},
+		},
+	}
+}
+
+func (r *listResourceImpl) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	util.ListResults(ctx, req, stream, func(config listConfigData) util.ListObjects[client.STaaSEnvironment] {
+		return util.ListObjects[client.STaaSEnvironment]{
+			ObjectType: "STaaS Environments",
+			Page:       r.client.STaaSEnvironment.Page,
+			Query:      config.Query,
+			Id:         func(environment client.STaaSEnvironment) string { return environment.Id },
+			Name:       func(environment client.STaaSEnvironment) string { return environment.Name },
+			Matches: func(environment client.STaaSEnvironment) bool {
+				return util.MatchesFilter(config.Name, environment.Name) &&
+					util.MatchesFilter(config.Cluster, environment.ClusterId, environment.Cluster) &&
+					util.MatchesFilter(config.Type, environment.Type)
+			},
+			Resource: func(id string) (any, diag.Diagnostics) {
+				var data resourceData
+				var diags diag.Diagnostics
+				staasEnvironment, err := r.client.STaaSEnvironment.Get(id)
+				if err != nil {
+					diags.AddError("Error while listing STaaS Environments", fmt.Sprintf("STaaS Environment %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				diags.Append(populateResourceData(ctx, &data, staasEnvironment)...)
+				return &data, diags
+			},
+		}
+	})
+}
@@ -13,11 +13,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
 	"github.com/previder/terraform-provider-previder/internal/util/sorters"
 	"github.com/previder/terraform-provider-previder/internal/util/validators"
-	"log"
 	"net"
 	"reflect"
 	"strings"
@@ -25,13 +25,20 @@ import (
 )
 
 const ResourceType = "previder_staas_environment"
+const logSubsystem = "previder.staas_environment"
+
+var stateUpgrades []util.StateUpgrade
 
 var _ resource.Resource = (*resourceImpl)(nil)
 var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
 var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithIdentity = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
 
 type resourceImpl struct {
-	client *client.PreviderClient
+	client         *client.PreviderClient
+	customer       string
+	customerClient util.CustomerClient
 }
 
 func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -42,17 +49,18 @@ func NewResource() resource.Resource {
 	return &resourceImpl{}
 }
 
-func (r *resourceImpl) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	var newDiags diag.Diagnostics
-	r.client, newDiags = util.ConfigureClient(req.ProviderData)
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
 	resp.Diagnostics.Append(newDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.customerClient = util.ConfigureCustomerClient(req.ProviderData)
 }
 
 func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
-
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
 	resp.Schema.Attributes = map[string]schema.Attribute{
 		"id": schema.StringAttribute{
 			MarkdownDescription: "ID of the STaas Environment",
@@ -163,7 +171,16 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 	}
 }
 
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = util.IdentitySchema()
+}
+
 func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -180,12 +197,25 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 		}
 	}
 
+	pending, diags := util.GetPendingCreate(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if pending != nil {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Checking interrupted STaaS environment create", map[string]any{util.LogFieldId: pending.Id, util.LogFieldState: environment.State})
+		if environment.State == "READY" {
+			resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
+		} else {
+			resp.Diagnostics.AddWarning("STaaS environment not ready", fmt.Sprintf("STaaS Environment (%s) is still being created (%s), it is checked again on the next refresh", pending.Id, environment.State))
+		}
+	}
+
 	populateResourceData(ctx, &data, environment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var plan resourceData
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
@@ -237,11 +267,23 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	err = waitForSTaaSEnvironmentState(r.client, plan.Id, "READY")
+	// The environment exists from now on, an interrupted create is checked by Read
+	resp.Diagnostics.Append(util.SetPendingCreate(ctx, resp.Private, util.PendingCreate{Id: plan.Id.ValueString()})...)
+
+	err = waitForSTaaSEnvironmentState(ctx, r.client, plan.Id, "READY")
 	if err != nil {
-		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error waiting for STaaS environment (%s) to become ready: %s", plan.Id, err))
+		resp.Diagnostics.Append(util.PendingCreateDiagnostics(ctx, "STaaS Environment", plan.Id.ValueString(), err)...)
+		// Volumes and networks are only added when the environment is ready, the next apply adds them. The state is
+		// read again, the environment may have changed while waiting.
+		if pendingEnvironment, err := r.client.STaaSEnvironment.Get(plan.Id.ValueString()); err == nil {
+			createdEnvironment = pendingEnvironment
+		}
+		populateResourceData(ctx, &plan, createdEnvironment)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: plan.Id})...)
 		return
 	}
+	resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
 
 	keys := sorters.SortMapKeys(plan.Volumes)
 
@@ -271,7 +313,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 
 		var volumeId = ""
 		for _, b := range createdEnvironment.Volumes {
-			log.Printf("[INFO] Found volumes in environment (%s) %s %s", b.Id, b.Name, b.State)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Found volume in STaaS environment", map[string]any{"volume_id": b.Id, "volume_name": b.Name, util.LogFieldState: b.State})
 
 			if types.StringValue(b.Name) == v.Name {
 				volumeId = b.Id
@@ -283,7 +325,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 			return
 		}
 
-		err = waitForSTaaSVolumeState(r.client, plan.Id, volumeId, "READY")
+		err = waitForSTaaSVolumeState(ctx, r.client, plan.Id, volumeId, "READY")
 	}
 
 	keys = sorters.SortMapKeys(plan.Networks)
@@ -300,7 +342,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 
 		var networkId = ""
 		for _, b := range createdEnvironment.Networks {
-			log.Printf("[INFO] Found network in environment (%s) %s %s", b.Id, b.NetworkName, b.State)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Found network in STaaS environment", map[string]any{"network_id": b.Id, "network_name": b.NetworkName, util.LogFieldState: b.State})
 
 			if types.StringValue(b.NetworkId) == n.NetworkId {
 				networkId = b.Id
@@ -312,7 +354,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 			return
 		}
 
-		err = waitForSTaaSNetworkState(r.client, plan.Id, networkId, []string{"READY", "SYNCED"})
+		err = waitForSTaaSNetworkState(ctx, r.client, plan.Id, networkId, []string{"READY", "SYNCED"})
 	}
 
 	createdEnvironment, err = r.client.STaaSEnvironment.Get(createdEnvironment.Id)
@@ -320,9 +362,11 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	populateResourceData(ctx, &plan, createdEnvironment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: plan.Id})...)
 }
 
 func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, plan resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -361,7 +405,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		update.Name = plan.Name.ValueString()
 		update.Windows = plan.Windows.ValueBool()
 
-		log.Printf("Updating environment %s", plan.Id.ValueString())
+		tflog.SubsystemInfo(ctx, logSubsystem, "Updating STaaS environment", map[string]any{util.LogFieldId: plan.Id.ValueString()})
 
 		err = r.client.STaaSEnvironment.Update(plan.Id.ValueString(), update)
 	}
@@ -374,7 +418,6 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		var found = false
 		for _, stateVolume := range state.Volumes {
 
-			log.Printf("Checking ID %s %s", planVolume.Id, stateVolume.Id)
 			if planVolume.Name == stateVolume.Name {
 				found = true
 				var changed = false
@@ -409,7 +452,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 
 					err = r.client.STaaSEnvironment.UpdateVolume(plan.Id.ValueString(), stateVolume.Id.ValueString(), update)
 
-					err = waitForSTaaSVolumeState(r.client, plan.Id, stateVolume.Id.ValueString(), "READY")
+					err = waitForSTaaSVolumeState(ctx, r.client, plan.Id, stateVolume.Id.ValueString(), "READY")
 				}
 			}
 
@@ -437,11 +480,11 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 				var environment, err2 = r.client.STaaSEnvironment.Get(plan.Id.ValueString())
 
 				if err2 != nil {
-					log.Printf("[INFO] Environment not found (%s) %s %s", plan.Id, plan.Name, plan.State)
+					tflog.SubsystemError(ctx, logSubsystem, "STaaS environment not found after creating volume", map[string]any{util.LogFieldId: plan.Id.ValueString(), util.LogFieldError: err2.Error()})
 				}
 				var volumeId = ""
 				for _, b := range environment.Volumes {
-					log.Printf("[INFO] Found volumes in environment (%s) %s %s", b.Id, b.Name, b.State)
+					tflog.SubsystemDebug(ctx, logSubsystem, "Found volume in STaaS environment", map[string]any{"volume_id": b.Id, "volume_name": b.Name, util.LogFieldState: b.State})
 
 					if types.StringValue(b.Name) == planVolume.Name {
 						volumeId = b.Id
@@ -453,7 +496,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 					return
 				}
 
-				err = waitForSTaaSVolumeState(r.client, plan.Id, volumeId, "READY")
+				err = waitForSTaaSVolumeState(ctx, r.client, plan.Id, volumeId, "READY")
 			}
 		}
 	}
@@ -478,7 +521,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 			deleteVolume.Force = true
 			err = r.client.STaaSEnvironment.DeleteVolume(plan.Id.ValueString(), stateVolume.Id.ValueString(), deleteVolume)
 
-			err = waitForSTaaSVolumeState(r.client, plan.Id, stateVolume.Id.ValueString(), "GRACE_TERMINATED")
+			err = waitForSTaaSVolumeState(ctx, r.client, plan.Id, stateVolume.Id.ValueString(), "GRACE_TERMINATED")
 		}
 	}
 
@@ -505,7 +548,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 
 			var networkId = ""
 			for _, b := range createdEnvironment.Networks {
-				log.Printf("[INFO] Found network in environment (%s) %s %s", b.Id, b.NetworkName, b.State)
+				tflog.SubsystemDebug(ctx, logSubsystem, "Found network in STaaS environment", map[string]any{"network_id": b.Id, "network_name": b.NetworkName, util.LogFieldState: b.State})
 
 				if types.StringValue(b.NetworkId) == planNetwork.NetworkId {
 					networkId = b.Id
@@ -517,7 +560,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 				return
 			}
 
-			err = waitForSTaaSNetworkState(r.client, plan.Id, networkId, []string{"READY", "SYNCED"})
+			err = waitForSTaaSNetworkState(ctx, r.client, plan.Id, networkId, []string{"READY", "SYNCED"})
 		}
 	}
 
@@ -533,7 +576,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		if !found {
 			err = r.client.STaaSEnvironment.DeleteNetwork(plan.Id.ValueString(), stateNetwork.Id.ValueString())
 
-			err = waitForSTaaSNetworkDeleted(r.client, plan.Id, stateNetwork.Id)
+			err = waitForSTaaSNetworkDeleted(ctx, r.client, plan.Id, stateNetwork.Id)
 		}
 	}
 
@@ -542,7 +585,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	err = waitForSTaaSEnvironmentState(r.client, plan.Id, "READY")
+	err = waitForSTaaSEnvironmentState(ctx, r.client, plan.Id, "READY")
 	if err != nil {
 		resp.Diagnostics.AddError("Error while waiting for environment to become ready", err.Error())
 		return
@@ -558,6 +601,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 	populateResourceData(ctx, &plan, updatedEnvironment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: plan.Id})...)
 
 }
 
@@ -588,6 +632,7 @@ func (r *resourceImpl) checkCidr(cidr types.String) diag.Diagnostics {
 }
 
 func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -595,36 +640,74 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	log.Printf("[INFO] Deleting STaaS environment: %s", data.Id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Deleting STaaS environment", map[string]any{util.LogFieldId: data.Id.ValueString()})
 	var deleteEnvironment client.STaaSEnvironmentDelete
 	deleteEnvironment.Force = true
 
 	err := r.client.STaaSEnvironment.Delete(data.Id.ValueString(), deleteEnvironment)
 
-	err = waitForSTaaSEnvironmentDeleted(r.client, data.Id)
+	err = waitForSTaaSEnvironmentDeleted(ctx, r.client, data.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error deleting STaaS environment: %s", err.Error())
 	}
 }
 
 func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
-	var environment, _ = r.client.STaaSEnvironment.Get(req.ID)
+	importId, newDiags := util.ImportIdFromRequest(ctx, req)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, customerId, newDiags := util.ResolveImportId(importId, r.listImportCandidates)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	importClient, newDiags := util.ImportClient(r.client, r.customer, r.customerClient, customerId)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	environment, err := importClient.STaaSEnvironment.Get(id)
+	if err != nil {
+		resp.Diagnostics.AddError("STaaS environment not found", fmt.Sprintf("Error while importing STaaS environment (%s): %s", id, err))
+		return
+	}
 
 	populateResourceData(ctx, &data, environment)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
+
+}
+
+// STaaS environments are not part of a group, so group/<group>/<name> imports never match
+func (r *resourceImpl) listImportCandidates(query string) ([]util.ImportCandidate, error) {
+	environments, err := util.PageAll(r.client.STaaSEnvironment.Page, query)
+	if err != nil {
+		return nil, err
+	}
 
+	var candidates []util.ImportCandidate
+	for _, environment := range environments {
+		candidates = append(candidates, util.ImportCandidate{Id: environment.Id, Name: environment.Name})
+	}
+	return candidates, nil
 }
 
-func waitForSTaaSEnvironmentState(client *client.PreviderClient, id types.String, target string) error {
-	log.Printf("[INFO] Waiting for STaaSEnvironment (%s) to have state %s", id, target)
+func waitForSTaaSEnvironmentState(ctx context.Context, client *client.PreviderClient, id types.String, target string) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for STaaS environment to have state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldTarget: target})
 
 	backoffOperation := func() error {
 		cluster, err := client.STaaSEnvironment.Get(id.ValueString())
 
 		if err != nil {
-			log.Printf("invalid STaaS Environment id: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "STaaS environment could not be fetched while waiting for state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid staas environment id: %s", id))
 		}
 		if cluster.State != target {
@@ -633,7 +716,7 @@ func waitForSTaaSEnvironmentState(client *client.PreviderClient, id types.String
 		return nil
 	}
 	// Max waiting time is 30 mins (should be max 10 mins for smaller clusters)
-	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 180)
+	backoffConfig := backoff.WithContext(backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 180), ctx)
 
 	err := backoff.Retry(backoffOperation, backoffConfig)
 	if err != nil {
@@ -643,14 +726,14 @@ func waitForSTaaSEnvironmentState(client *client.PreviderClient, id types.String
 	return nil
 }
 
-func waitForSTaaSVolumeState(client *client.PreviderClient, id types.String, volumeId string, target string) error {
-	log.Printf("[INFO] Waiting for STaaSVolume (%s) to have state %s", id, target)
+func waitForSTaaSVolumeState(ctx context.Context, client *client.PreviderClient, id types.String, volumeId string, target string) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for STaaS volume to have state", map[string]any{util.LogFieldId: id.ValueString(), "volume_id": volumeId, util.LogFieldTarget: target})
 
 	backoffOperation := func() error {
 		cluster, err := client.STaaSEnvironment.Get(id.ValueString())
 
 		if err != nil {
-			log.Printf("invalid STaaS Environment id: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "STaaS environment could not be fetched while waiting for state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid staas volume id: %s", id))
 		}
 
@@ -672,14 +755,14 @@ func waitForSTaaSVolumeState(client *client.PreviderClient, id types.String, vol
 	return nil
 }
 
-func waitForSTaaSNetworkState(client *client.PreviderClient, id types.String, networkId string, target []string) error {
-	log.Printf("[INFO] Waiting for STaaSNetwork (%s) to have state %s", id, target)
+func waitForSTaaSNetworkState(ctx context.Context, client *client.PreviderClient, id types.String, networkId string, target []string) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for STaaS network to have state", map[string]any{util.LogFieldId: id.ValueString(), "network_id": networkId, util.LogFieldTarget: strings.Join(target, ",")})
 
 	backoffOperation := func() error {
 		cluster, err := client.STaaSEnvironment.Get(id.ValueString())
 
 		if err != nil {
-			log.Printf("invalid STaaS Environment id: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "STaaS environment could not be fetched while waiting for state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid staas environment id: %s", id))
 		}
 
@@ -710,26 +793,24 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func waitForSTaaSEnvironmentDeleted(client *client.PreviderClient, id types.String) error {
+func waitForSTaaSEnvironmentDeleted(ctx context.Context, client *client.PreviderClient, id types.String) error {
 	backoffOperation := func() error {
 		cluster, err := client.STaaSEnvironment.Get(id.ValueString())
-		log.Printf("Fetching environment: %v", id)
 		if err != nil {
-			log.Printf("Error while fetching a environment")
 			if strings.Contains(err.Error(), "404") &&
 				strings.Contains(err.Error(), "not found") {
 				return nil
 			}
-			log.Printf("Environment still exists, but got an error: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "STaaS environment still exists, but got an error", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("environment still exists, but got an error: %s", id.ValueString()))
 		}
 		if cluster.State == "FORCE_REMOVAL" {
-			log.Printf("Waiting for environment to be gone: %v", cluster.State)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for STaaS environment to be gone", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldState: cluster.State})
 			return errors.New(fmt.Sprintf("Waiting for environment to be gone: %s", id.ValueString()))
 		}
 		return nil
 	}
-	log.Printf("Waiting for environment deletion: %v", id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for STaaS environment deletion", map[string]any{util.LogFieldId: id.ValueString()})
 	// Max waiting time is 20 mins
 	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 120)
 
@@ -741,23 +822,21 @@ func waitForSTaaSEnvironmentDeleted(client *client.PreviderClient, id types.Stri
 	return nil
 }
 
-func waitForSTaaSNetworkDeleted(client *client.PreviderClient, id types.String, networkId types.String) error {
+func waitForSTaaSNetworkDeleted(ctx context.Context, client *client.PreviderClient, id types.String, networkId types.String) error {
 	backoffOperation := func() error {
 		cluster, err := client.STaaSEnvironment.Get(id.ValueString())
-		log.Printf("Fetching environment: %v", id)
 		if err != nil {
-			log.Printf("Error while fetching a environment")
 			if strings.Contains(err.Error(), "404") &&
 				strings.Contains(err.Error(), "not found") {
 				return nil
 			}
-			log.Printf("Environment still exists, but got an error: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "STaaS environment still exists, but got an error", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("environment still exists, but got an error: %s", id.ValueString()))
 		}
 		for _, n := range cluster.Networks {
 			if n.Id == networkId.ValueString() {
 				if n.State == "PENDING_REMOVAL" {
-					log.Printf("Waiting for network to be gone: %v", cluster.State)
+					tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for STaaS network to be gone", map[string]any{util.LogFieldId: id.ValueString(), "network_id": networkId.ValueString(), util.LogFieldState: n.State})
 					return errors.New(fmt.Sprintf("Waiting for network to be gone: %s", networkId.ValueString()))
 				}
 			}
@@ -765,7 +844,7 @@ func waitForSTaaSNetworkDeleted(client *client.PreviderClient, id types.String,
 
 		return nil
 	}
-	log.Printf("Waiting for network deletion: %v", id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for STaaS network deletion", map[string]any{util.LogFieldId: id.ValueString(), "network_id": networkId.ValueString()})
 	// Max waiting time is 20 mins
 	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 120)
 
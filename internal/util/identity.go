@@ -0,0 +1,120 @@
+package util
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+)
+
+// ResourceIdentity is the identity shared by all resources, objects are identified by their ObjectId
+type ResourceIdentity struct {
+	Id types.String `tfsdk:"id"`
+}
+
+func IdentitySchema() identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "ObjectId of the object",
+			},
+		},
+	}
+}
+
+// ImportIdFromRequest returns the ID given to terraform import, or the id from the identity in an import block
+func ImportIdFromRequest(ctx context.Context, req resource.ImportStateRequest) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if req.ID != "" || req.Identity == nil {
+		return req.ID, diags
+	}
+
+	var id types.String
+	diags.Append(req.Identity.GetAttribute(ctx, path.Root("id"), &id)...)
+
+	return id.ValueString(), diags
+}
+
+// MatchesFilter returns true when the filter is not set or equals one of the values
+func MatchesFilter(filter types.String, values ...string) bool {
+	if filter.IsNull() || filter.IsUnknown() || filter.ValueString() == "" {
+		return true
+	}
+	for _, value := range values {
+		if value == filter.ValueString() {
+			return true
+		}
+	}
+	return false
+}
+
+// ListObjects describes how a list resource finds its objects
+type ListObjects[T any] struct {
+	// ObjectType is used in error messages, like Virtual Networks
+	ObjectType string
+	Page       func(request client.PageRequest) (*client.Page, *[]T, error)
+	Query      types.String
+	Id         func(object T) string
+	Name       func(object T) string
+	// Matches returns false for objects excluded by the filters of the list config
+	Matches func(object T) bool
+	// Resource returns the resource data of an object, it is only called when the resource is included in the result
+	Resource func(id string) (any, diag.Diagnostics)
+}
+
+// ListResults streams the objects of a list resource with their identity. The list config is read into C and passed
+// to objects, which returns how the objects are listed.
+func ListResults[C any, T any](ctx context.Context, req list.ListRequest, stream *list.ListResultsStream, objects func(config C) ListObjects[T]) {
+	var config C
+	var diags diag.Diagnostics
+
+	diags.Append(req.Config.Get(ctx, &config)...)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	listObjects := objects(config)
+	listed, err := PageAll(listObjects.Page, listObjects.Query.ValueString())
+	if err != nil {
+		diags.AddError("Error while listing "+listObjects.ObjectType, err.Error())
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, object := range listed {
+			if !listObjects.Matches(object) {
+				continue
+			}
+			if req.Limit > 0 && count >= req.Limit {
+				return
+			}
+			count++
+
+			id := listObjects.Id(object)
+			result := req.NewListResult(ctx)
+			result.DisplayName = listObjects.Name(object)
+			result.Diagnostics.Append(result.Identity.Set(ctx, ResourceIdentity{Id: types.StringValue(id)})...)
+
+			if req.IncludeResource && !result.Diagnostics.HasError() {
+				data, newDiags := listObjects.Resource(id)
+				result.Diagnostics.Append(newDiags...)
+				if !newDiags.HasError() {
+					result.Diagnostics.Append(result.Resource.Set(ctx, data)...)
+				}
+			}
+
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package util
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/previder/previder-go-sdk/client"
+	"strings"
+)
+
+const (
+	importPrefixName     = "name:"
+	importPrefixGroup    = "group/"
+	importPrefixCustomer = "customer/"
+)
+
+// ImportId is the parsed form of the ID given to terraform import
+//
+// Supported forms are:
+//   - <id>
+//   - name:<name>
+//   - group/<group>/<name>
+//   - customer/<customerId>/<id>
+type ImportId struct {
+	Id         string
+	Name       string
+	Group      string
+	CustomerId string
+}
+
+// ImportCandidate is a listed object which can be matched against an ImportId
+type ImportCandidate struct {
+	Id        string
+	Name      string
+	Group     string
+	GroupName string
+}
+
+func ParseImportId(in string) (*ImportId, error) {
+	switch {
+	case strings.HasPrefix(in, importPrefixName):
+		name := strings.TrimPrefix(in, importPrefixName)
+		if name == "" {
+			return nil, fmt.Errorf("import ID %q does not contain a name, expected name:<name>", in)
+		}
+		return &ImportId{Name: name}, nil
+	case strings.HasPrefix(in, importPrefixGroup):
+		parts := strings.SplitN(strings.TrimPrefix(in, importPrefixGroup), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("import ID %q is invalid, expected group/<group>/<name>", in)
+		}
+		return &ImportId{Group: parts[0], Name: parts[1]}, nil
+	case strings.HasPrefix(in, importPrefixCustomer):
+		parts := strings.SplitN(strings.TrimPrefix(in, importPrefixCustomer), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("import ID %q is invalid, expected customer/<customerId>/<id>", in)
+		}
+		if !IsValidObjectId(parts[0]) {
+			return nil, fmt.Errorf("customer %q in import ID is not a valid ObjectId", parts[0])
+		}
+		if !IsValidObjectId(parts[1]) {
+			return nil, fmt.Errorf("object %q in import ID is not a valid ObjectId", parts[1])
+		}
+		return &ImportId{CustomerId: parts[0], Id: parts[1]}, nil
+	}
+
+	if in == "" {
+		return nil, fmt.Errorf("import ID cannot be empty")
+	}
+	if !IsValidObjectId(in) {
+		return nil, fmt.Errorf("import ID %q is not a valid ObjectId, expected <id>, name:<name>, group/<group>/<name> or customer/<customerId>/<id>", in)
+	}
+	return &ImportId{Id: in}, nil
+}
+
+// ResolveImportId translates the import ID to the ID of a single object and the customer it has to be looked up
+// with. The customer is empty unless the customer/<customerId>/<id> form is used. Name based imports are looked up
+// through the list function, which receives the name as query.
+func ResolveImportId(in string, list func(query string) ([]ImportCandidate, error)) (string, string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	importId, err := ParseImportId(in)
+	if err != nil {
+		diags.AddError("Invalid import ID", err.Error())
+		return "", "", diags
+	}
+
+	if importId.Name == "" {
+		return importId.Id, importId.CustomerId, diags
+	}
+
+	listed, err := list(importId.Name)
+	if err != nil {
+		diags.AddError("Error while searching for import", fmt.Sprintf("Objects could not be listed: %s", err))
+		return "", "", diags
+	}
+
+	var matches []ImportCandidate
+	for _, candidate := range listed {
+		if candidate.Name != importId.Name {
+			continue
+		}
+		if importId.Group != "" {
+			if IsValidObjectId(importId.Group) && candidate.Group != importId.Group {
+				continue
+			}
+			if !IsValidObjectId(importId.Group) && candidate.GroupName != importId.Group {
+				continue
+			}
+		}
+		matches = append(matches, candidate)
+	}
+
+	if len(matches) == 0 {
+		diags.AddError("Object not found", fmt.Sprintf("No object found for import ID %s", in))
+		return "", "", diags
+	}
+	if len(matches) > 1 {
+		var candidates []string
+		for _, match := range matches {
+			if match.GroupName != "" {
+				candidates = append(candidates, fmt.Sprintf("%s (group %s, id %s)", match.Name, match.GroupName, match.Id))
+			} else {
+				candidates = append(candidates, fmt.Sprintf("%s (id %s)", match.Name, match.Id))
+			}
+		}
+		diags.AddError("Ambiguous import ID", fmt.Sprintf("Import ID %s matches %d objects, import one of them by group/<group>/<name> or by ID:\n%s", in, len(matches), strings.Join(candidates, "\n")))
+		return "", "", diags
+	}
+
+	return matches[0].Id, "", diags
+}
+
+// ImportClient returns the client to look up an imported object with. Objects of the customer of the provider, or
+// without a customer in the import ID, use the client of the provider. Other customers get a client scoped to that
+// customer.
+func ImportClient(baseClient *client.PreviderClient, providerCustomerId string, customerClient CustomerClient, customerId string) (*client.PreviderClient, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if customerId == "" || customerId == providerCustomerId {
+		return baseClient, diags
+	}
+	if customerClient == nil {
+		diags.AddError("Invalid import ID", fmt.Sprintf("Objects of customer %s cannot be imported, the provider is not configured", customerId))
+		return nil, diags
+	}
+
+	scopedClient, err := customerClient(customerId)
+	if err != nil {
+		diags.AddError("Invalid import ID", fmt.Sprintf("Client for customer %s could not be created: %s", customerId, err))
+		return nil, diags
+	}
+
+	diags.AddWarning("Imported object of another customer",
+		fmt.Sprintf("The object is imported from customer %s, the provider is configured with customer %q. Refreshes and changes use the customer of the provider, configure the provider with customer = %q to manage this object.", customerId, providerCustomerId, customerId))
+	return scopedClient, diags
+}
+
+// PageAll walks over all pages of a Page function of the Previder client
+func PageAll[T any](page func(request client.PageRequest) (*client.Page, *[]T, error), query string) ([]T, error) {
+	var result []T
+
+	request := client.PageRequest{
+		Size:  100,
+		Page:  0,
+		Sort:  "+name",
+		Query: query,
+	}
+
+	for {
+		pageInfo, content, err := page(request)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *content...)
+		if request.Page+1 >= pageInfo.TotalPages {
+			break
+		}
+		request.Page++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImportId(t *testing.T) {
+	const id = "5f3a2b1c4d5e6f7a8b9c0d1e"
+	const customerId = "6a1b2c3d4e5f6a7b8c9d0e1f"
+
+	tests := []struct {
+		name    string
+		in      string
+		want    *ImportId
+		wantErr bool
+	}{
+		{name: "id", in: id, want: &ImportId{Id: id}},
+		{name: "name", in: "name:web01", want: &ImportId{Name: "web01"}},
+		{name: "group", in: "group/Production/web01", want: &ImportId{Group: "Production", Name: "web01"}},
+		{name: "customer", in: "customer/" + customerId + "/" + id, want: &ImportId{CustomerId: customerId, Id: id}},
+		{name: "empty", in: "", wantErr: true},
+		{name: "invalid id", in: "web01", wantErr: true},
+		{name: "empty name", in: "name:", wantErr: true},
+		{name: "group without name", in: "group/Production", wantErr: true},
+		{name: "customer without id", in: "customer/" + customerId, wantErr: true},
+		{name: "invalid customer", in: "customer/acme/" + id, wantErr: true},
+		{name: "invalid customer object", in: "customer/" + customerId + "/web01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImportId(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
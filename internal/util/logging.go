@@ -0,0 +1,37 @@
+package util
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Structured field keys used in all log messages
+const (
+	LogFieldId       = "id"
+	LogFieldTaskId   = "task_id"
+	LogFieldCustomer = "customer"
+	LogFieldState    = "state"
+	LogFieldTarget   = "target_state"
+	LogFieldError    = "error"
+)
+
+// sensitiveLogFields are never written to the logs, their values are replaced by ***
+var sensitiveLogFields = []string{
+	"token",
+	"kubeconfig",
+	"initial_password",
+	"user_data",
+	"user_data_wo",
+}
+
+// NewLogContext returns a context with a logging subsystem for a resource, like previder.virtual_server.
+// The customer is added to every message and sensitive fields are masked.
+func NewLogContext(ctx context.Context, subsystem string, customer string) context.Context {
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, sensitiveLogFields...)
+	ctx = tflog.NewSubsystem(ctx, subsystem)
+	ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, subsystem, sensitiveLogFields...)
+	if customer != "" {
+		ctx = tflog.SubsystemSetField(ctx, subsystem, LogFieldCustomer, customer)
+	}
+	return ctx
+}
@@ -0,0 +1,76 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// privateStateKeyPendingCreate is the private state key of a create that has not finished
+const privateStateKeyPendingCreate = "pending_create"
+
+// PendingCreate is an object that has been created, but was not ready when the create was interrupted or failed
+type PendingCreate struct {
+	Id     string `json:"id"`
+	TaskId string `json:"task_id,omitempty"`
+}
+
+// PrivateStateReader is implemented by the private state of read, plan and update requests
+type PrivateStateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// PrivateStateWriter is implemented by the private state of create, read and update responses
+type PrivateStateWriter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// SetPendingCreate records an object that is created but not ready yet, a later read checks whether it became ready
+func SetPendingCreate(ctx context.Context, private PrivateStateWriter, pending PendingCreate) diag.Diagnostics {
+	value, err := json.Marshal(pending)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Pending create not recorded", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, privateStateKeyPendingCreate, value)
+}
+
+// GetPendingCreate returns the pending create, nil when the create has finished
+func GetPendingCreate(ctx context.Context, private PrivateStateReader) (*PendingCreate, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, privateStateKeyPendingCreate)
+	if diags.HasError() || len(value) == 0 {
+		return nil, diags
+	}
+
+	var pending PendingCreate
+	err := json.Unmarshal(value, &pending)
+	if err != nil {
+		diags.AddError("Invalid pending create", err.Error())
+		return nil, diags
+	}
+	return &pending, diags
+}
+
+// ClearPendingCreate removes the pending create when the object is ready
+func ClearPendingCreate(ctx context.Context, private PrivateStateWriter) diag.Diagnostics {
+	return private.SetKey(ctx, privateStateKeyPendingCreate, nil)
+}
+
+// CreateInterrupted reports whether Terraform stopped the provider, like after Ctrl-C, while a create was waiting
+func CreateInterrupted(ctx context.Context) bool {
+	return ctx.Err() != nil
+}
+
+// PendingCreateDiagnostics reports an object that was created but did not become ready. The object is stored in the
+// state with it, an interrupted create is checked by the next read and other failures leave a tainted object.
+func PendingCreateDiagnostics(ctx context.Context, objectType string, id string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if CreateInterrupted(ctx) {
+		diags.AddWarning(fmt.Sprintf("%s create interrupted", objectType), fmt.Sprintf("%s (%s) is created but not ready yet, it is checked again on the next refresh", objectType, id))
+	} else {
+		diags.AddError(fmt.Sprintf("%s not ready", objectType), fmt.Sprintf("Error waiting for %s (%s) to become ready, it is replaced on the next apply: %s", objectType, id, err))
+	}
+	return diags
+}
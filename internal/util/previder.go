@@ -1,40 +1,60 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
-	"log"
 )
 
-func ConfigureClient(providerData any) (*client.PreviderClient, diag.Diagnostics) {
+// ProviderData is handed to every resource by the provider Configure method
+type ProviderData struct {
+	Client     *client.PreviderClient
+	CustomerId string
+	// CustomerClient creates a client with the credentials of the provider for another customer
+	CustomerClient CustomerClient
+}
+
+// CustomerClient creates a client which sends its requests on behalf of the given customer
+type CustomerClient func(customerId string) (*client.PreviderClient, error)
+
+func ConfigureClient(ctx context.Context, providerData any) (*client.PreviderClient, string, diag.Diagnostics) {
 	var diagnostics diag.Diagnostics
 
 	if providerData == nil {
-		return nil, diagnostics
+		return nil, "", diagnostics
 	}
 
-	baseClient, ok := providerData.(*client.PreviderClient)
+	data, ok := providerData.(*ProviderData)
 	if !ok {
-		log.Println("Got the data")
 		diagnostics.AddError(
 			"Unexpected Configure Type",
-			fmt.Sprintf("Expected *client.BaseClient, got: %T. Please report this issue to the provider developers.", providerData),
+			fmt.Sprintf("Expected *util.ProviderData, got: %T. Please report this issue to the provider developers.", providerData),
 		)
-		return nil, diagnostics
+		return nil, "", diagnostics
 	}
 
-	log.Printf("Trying to fetch API information")
-	result, err := baseClient.ApiInfo()
+	tflog.Debug(ctx, "Trying to fetch API information")
+	result, err := data.Client.ApiInfo()
 	if err != nil {
 		diagnostics.AddError(
 			"Invalid client or token",
 			fmt.Sprintf("API could not be queried: %v", err),
 		)
-		return nil, diagnostics
+		return nil, "", diagnostics
 	}
 
-	log.Printf("API version %s", result.Version)
+	tflog.Debug(ctx, "Previder API information", map[string]any{"api_version": result.Version})
 
-	return baseClient, diagnostics
+	return data.Client, data.CustomerId, diagnostics
+}
+
+// ConfigureCustomerClient returns the CustomerClient of the provider, or nil when the provider is not configured yet
+func ConfigureCustomerClient(providerData any) CustomerClient {
+	data, ok := providerData.(*ProviderData)
+	if !ok {
+		return nil
+	}
+	return data.CustomerClient
 }
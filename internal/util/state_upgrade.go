@@ -0,0 +1,143 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"slices"
+)
+
+// StateUpgrade changes the raw JSON state of a resource from one schema version to the next. Attributes that are not
+// changed are kept as they are, so the prior schema does not have to be declared again.
+type StateUpgrade func(state map[string]any) error
+
+// Every resource keeps an ordered list of state upgrades, upgrade i changes the state from schema version i to i+1.
+// A structural schema change adds an upgrade to the end of the list, the schema version follows automatically:
+//
+//	var stateUpgrades = []util.StateUpgrade{
+//		func(state map[string]any) error { return util.ListToSet(state, "tags") },
+//	}
+//
+//	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
+//
+//	func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+//		return util.StateUpgraders(stateUpgrades)
+//	}
+
+// SchemaVersion returns the current schema version of a resource with the upgrades
+func SchemaVersion(upgrades []StateUpgrade) int64 {
+	return int64(len(upgrades))
+}
+
+// StateUpgraders returns an upgrader for every prior schema version, it applies all upgrades from that version to the
+// current version in order
+func StateUpgraders(upgrades []StateUpgrade) map[int64]resource.StateUpgrader {
+	upgraders := make(map[int64]resource.StateUpgrader, len(upgrades))
+	for version := range upgrades {
+		upgraders[int64(version)] = UpgradeStateJSON(func(state map[string]any) error {
+			for step, upgrade := range upgrades[version:] {
+				err := upgrade(state)
+				if err != nil {
+					return fmt.Errorf("upgrade from schema version %d to %d failed: %w", version+step, version+step+1, err)
+				}
+			}
+			return nil
+		})
+	}
+	return upgraders
+}
+
+// UpgradeStateJSON returns a state upgrader that changes the raw JSON state of a resource
+func UpgradeStateJSON(upgrade StateUpgrade) resource.StateUpgrader {
+	return resource.StateUpgrader{
+		StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+			if req.RawState == nil || req.RawState.JSON == nil {
+				resp.Diagnostics.AddError("State not upgraded", "The state was written by a Terraform version that is not supported, apply with Terraform 0.12 or later first")
+				return
+			}
+
+			upgraded, err := upgradeStateJSON(req.RawState.JSON, upgrade)
+			if err != nil {
+				resp.Diagnostics.AddError("State not upgraded", err.Error())
+				return
+			}
+			resp.DynamicValue = &tfprotov6.DynamicValue{JSON: upgraded}
+		},
+	}
+}
+
+func upgradeStateJSON(raw []byte, upgrade StateUpgrade) ([]byte, error) {
+	// Numbers are kept as written, large integers like disk sizes would lose precision as float64
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var state map[string]any
+	err := decoder.Decode(&state)
+	if err != nil {
+		return nil, fmt.Errorf("the state could not be read: %w", err)
+	}
+
+	err = upgrade(state)
+	if err != nil {
+		return nil, err
+	}
+
+	upgraded, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("the state could not be written: %w", err)
+	}
+	return upgraded, nil
+}
+
+// RenameAttribute moves the value of an attribute to a new name
+func RenameAttribute(state map[string]any, from string, to string) error {
+	value, ok := state[from]
+	if !ok {
+		return nil
+	}
+	if _, exists := state[to]; exists {
+		return fmt.Errorf("attribute %s cannot be renamed to %s, the attribute already exists", from, to)
+	}
+	state[to] = value
+	delete(state, from)
+	return nil
+}
+
+// RemoveAttribute removes an attribute that is no longer in the schema
+func RemoveAttribute(state map[string]any, attribute string) error {
+	delete(state, attribute)
+	return nil
+}
+
+// ListToSet changes a list of strings in the state to a set, the values are sorted and duplicates are removed
+func ListToSet(state map[string]any, attribute string) error {
+	value, ok := state[attribute]
+	if !ok || value == nil {
+		return nil
+	}
+
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("attribute %s is not a list", attribute)
+	}
+	var values []string
+	for _, element := range list {
+		s, ok := element.(string)
+		if !ok {
+			return fmt.Errorf("attribute %s is not a list of strings", attribute)
+		}
+		values = append(values, s)
+	}
+
+	slices.Sort(values)
+	values = slices.Compact(values)
+	set := make([]any, len(values))
+	for i, s := range values {
+		set[i] = s
+	}
+	state[attribute] = set
+	return nil
+}
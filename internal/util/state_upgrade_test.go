@@ -0,0 +1,218 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// upgradeState runs a state upgrader on raw JSON state and returns the upgraded JSON or the error diagnostic
+func upgradeState(t *testing.T, upgrader resource.StateUpgrader, rawState *tfprotov6.RawState) (string, string) {
+	t.Helper()
+	var resp resource.UpgradeStateResponse
+	upgrader.StateUpgrader(context.Background(), resource.UpgradeStateRequest{RawState: rawState}, &resp)
+	if resp.Diagnostics.HasError() {
+		return "", resp.Diagnostics.Errors()[0].Detail()
+	}
+	if resp.DynamicValue == nil {
+		t.Fatal("upgrader returned no error and no state")
+	}
+	return string(resp.DynamicValue.JSON), ""
+}
+
+func decodeState(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var state map[string]any
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		t.Fatalf("invalid state %s: %s", raw, err)
+	}
+	return state
+}
+
+var testUpgrades = []StateUpgrade{
+	func(state map[string]any) error { return RenameAttribute(state, "labels", "tags") },
+	func(state map[string]any) error { return ListToSet(state, "tags") },
+	func(state map[string]any) error { return RemoveAttribute(state, "legacy") },
+}
+
+func TestStateUpgraders(t *testing.T) {
+	want := map[string]any{"id": "abc", "tags": []any{"a", "b"}}
+
+	tests := []struct {
+		name    string
+		version int64
+		state   string
+	}{
+		{name: "from version 0", version: 0, state: `{"id":"abc","labels":["b","a","b"],"legacy":true}`},
+		{name: "from version 1", version: 1, state: `{"id":"abc","tags":["b","a","b"],"legacy":true}`},
+		{name: "from version 2", version: 2, state: `{"id":"abc","tags":["a","b"],"legacy":true}`},
+	}
+
+	upgraders := StateUpgraders(testUpgrades)
+	if len(upgraders) != len(testUpgrades) {
+		t.Fatalf("got %d upgraders, want %d", len(upgraders), len(testUpgrades))
+	}
+	if _, ok := upgraders[SchemaVersion(testUpgrades)]; ok {
+		t.Fatal("the current schema version must not have an upgrader")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrader, ok := upgraders[tt.version]
+			if !ok {
+				t.Fatalf("no upgrader for version %d", tt.version)
+			}
+			got, errorDetail := upgradeState(t, upgrader, &tfprotov6.RawState{JSON: []byte(tt.state)})
+			if errorDetail != "" {
+				t.Fatalf("unexpected error: %s", errorDetail)
+			}
+			if !reflect.DeepEqual(decodeState(t, got), want) {
+				t.Errorf("got %s, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStateUpgradersError(t *testing.T) {
+	failing := []StateUpgrade{
+		func(state map[string]any) error { return nil },
+		func(state map[string]any) error { return nil },
+		func(state map[string]any) error { return errors.New("broken") },
+		func(state map[string]any) error { return nil },
+	}
+
+	tests := []struct {
+		name    string
+		version int64
+		want    string
+	}{
+		{name: "chained from version 0", version: 0, want: "upgrade from schema version 2 to 3 failed: broken"},
+		{name: "chained from version 1", version: 1, want: "upgrade from schema version 2 to 3 failed: broken"},
+		{name: "from the failing version", version: 2, want: "upgrade from schema version 2 to 3 failed: broken"},
+		{name: "after the failing version", version: 3, want: ""},
+	}
+
+	upgraders := StateUpgraders(failing)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errorDetail := upgradeState(t, upgraders[tt.version], &tfprotov6.RawState{JSON: []byte(`{"id":"abc"}`)})
+			if errorDetail != tt.want {
+				t.Errorf("got error %q, want %q", errorDetail, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeStateJSONWithoutJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawState *tfprotov6.RawState
+	}{
+		{name: "nil raw state", rawState: nil},
+		{name: "nil JSON", rawState: &tfprotov6.RawState{Flatmap: map[string]string{"id": "abc"}}},
+	}
+
+	upgrader := UpgradeStateJSON(func(state map[string]any) error {
+		t.Error("upgrade must not be called without JSON state")
+		return nil
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errorDetail := upgradeState(t, upgrader, tt.rawState)
+			if !strings.Contains(errorDetail, "Terraform 0.12 or later") {
+				t.Errorf("got error %q, want an error about old Terraform versions", errorDetail)
+			}
+		})
+	}
+}
+
+func TestUpgradeStateJSONKeepsNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  string
+		number string
+	}{
+		{name: "integer above float64 precision", state: `{"size":9007199254740993}`, number: "9007199254740993"},
+		{name: "largest int64", state: `{"size":9223372036854775807}`, number: "9223372036854775807"},
+		{name: "decimal", state: `{"size":0.1}`, number: "0.1"},
+		{name: "nested", state: `{"disks":{"data":{"size":18014398509481985}}}`, number: "18014398509481985"},
+	}
+
+	upgrader := UpgradeStateJSON(func(state map[string]any) error { return nil })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errorDetail := upgradeState(t, upgrader, &tfprotov6.RawState{JSON: []byte(tt.state)})
+			if errorDetail != "" {
+				t.Fatalf("unexpected error: %s", errorDetail)
+			}
+			if !strings.Contains(got, tt.number) {
+				t.Errorf("got %s, want it to contain %s", got, tt.number)
+			}
+		})
+	}
+}
+
+func TestListToSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   map[string]any
+		want    map[string]any
+		wantErr string
+	}{
+		{name: "missing", state: map[string]any{"id": "abc"}, want: map[string]any{"id": "abc"}},
+		{name: "null", state: map[string]any{"tags": nil}, want: map[string]any{"tags": nil}},
+		{name: "empty", state: map[string]any{"tags": []any{}}, want: map[string]any{"tags": []any{}}},
+		{name: "sorted", state: map[string]any{"tags": []any{"web", "app"}}, want: map[string]any{"tags": []any{"app", "web"}}},
+		{name: "duplicates", state: map[string]any{"tags": []any{"web", "app", "web", "app"}}, want: map[string]any{"tags": []any{"app", "web"}}},
+		{name: "not a list", state: map[string]any{"tags": "web"}, wantErr: "attribute tags is not a list"},
+		{name: "not a list of strings", state: map[string]any{"tags": []any{"web", json.Number("1")}}, wantErr: "attribute tags is not a list of strings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ListToSet(tt.state, "tags")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(tt.state, tt.want) {
+				t.Errorf("got %v, want %v", tt.state, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameAttribute(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   map[string]any
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "renamed", state: map[string]any{"labels": []any{"web"}}, want: map[string]any{"tags": []any{"web"}}},
+		{name: "missing", state: map[string]any{"id": "abc"}, want: map[string]any{"id": "abc"}},
+		{name: "target exists", state: map[string]any{"labels": []any{"web"}, "tags": []any{"app"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenameAttribute(tt.state, "labels", "tags")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.state, tt.want) {
+				t.Errorf("got %v, want %v", tt.state, tt.want)
+			}
+		})
+	}
+}
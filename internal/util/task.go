@@ -0,0 +1,114 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/previder/previder-go-sdk/client"
+	"time"
+)
+
+// WaitForTask waits for a task like client.TaskService.WaitFor, but stops when the context is cancelled. The task is
+// returned with an error when it failed, nil is returned when the task did not complete in time.
+func WaitForTask(ctx context.Context, baseClient *client.PreviderClient, id string, timeout time.Duration) (*client.Task, error) {
+	return WaitForTaskProgress(ctx, baseClient, id, timeout, nil)
+}
+
+// WaitForTaskProgress waits for a task like WaitForTask. progress is called with the task whenever its progress
+// changes, it may be nil.
+func WaitForTaskProgress(ctx context.Context, baseClient *client.PreviderClient, id string, timeout time.Duration, progress func(task *client.Task)) (*client.Task, error) {
+	var task *client.Task
+	lastProgress := -1
+	backoffOperation := func() error {
+		var err error
+		task, err = baseClient.Task.Get(id)
+		if err != nil {
+			task = nil
+			return err
+		}
+		if progress != nil && task.Progress != lastProgress {
+			lastProgress = task.Progress
+			progress(task)
+		}
+		if !task.Completed {
+			return errors.New("task did not complete in time")
+		}
+		return nil
+	}
+
+	interval := 3 * time.Second
+	backoffConfig := backoff.WithContext(backoff.WithMaxRetries(backoff.NewConstantBackOff(interval), uint64(timeout/interval)), ctx)
+
+	err := backoff.Retry(backoffOperation, backoffConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !task.Success {
+		return task, errors.New(task.ErrorMessage)
+	}
+	return task, nil
+}
+
+// TaskError is a task that failed or did not complete, with the last known state of the object it changed
+type TaskError struct {
+	TaskId      string
+	TaskType    string
+	Progress    int
+	Message     string
+	ObjectState string
+}
+
+func (e *TaskError) Error() string {
+	message := fmt.Sprintf("task %s", e.TaskId)
+	if e.TaskType != "" {
+		message += fmt.Sprintf(" (%s)", e.TaskType)
+	}
+	message += fmt.Sprintf(" failed at %d%%: %s", e.Progress, e.Message)
+	if e.ObjectState != "" {
+		message += fmt.Sprintf(", last known state %s", e.ObjectState)
+	}
+	return message
+}
+
+// CheckTask waits for a task and returns a *TaskError when it failed or did not complete in time. objectState returns
+// the current state of the changed object and is only called on failure, it may be nil.
+func CheckTask(ctx context.Context, baseClient *client.PreviderClient, id string, timeout time.Duration, objectState func() string) (*client.Task, error) {
+	return CheckTaskProgress(ctx, baseClient, id, timeout, objectState, nil)
+}
+
+// CheckTaskProgress checks a task like CheckTask, progress is called like with WaitForTaskProgress
+func CheckTaskProgress(ctx context.Context, baseClient *client.PreviderClient, id string, timeout time.Duration, objectState func() string, progress func(task *client.Task)) (*client.Task, error) {
+	task, err := WaitForTaskProgress(ctx, baseClient, id, timeout, progress)
+	if err == nil {
+		return task, nil
+	}
+
+	taskError := &TaskError{TaskId: id, Message: err.Error()}
+	if task == nil {
+		// The task did not complete, the last known progress is fetched for the message
+		task, _ = baseClient.Task.Get(id)
+	}
+	if task != nil {
+		taskError.TaskType = task.TaskType
+		taskError.Progress = task.Progress
+		if task.ErrorMessage != "" {
+			taskError.Message = task.ErrorMessage
+		}
+	}
+	if objectState != nil {
+		taskError.ObjectState = objectState()
+	}
+	return task, taskError
+}
+
+// VirtualMachineState returns the state of a virtual machine for a *TaskError
+func VirtualMachineState(baseClient *client.PreviderClient, id string) func() string {
+	return func() string {
+		vm, err := GetVirtualMachine(baseClient, id)
+		if err != nil {
+			return ""
+		}
+		return vm.State
+	}
+}
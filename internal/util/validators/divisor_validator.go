@@ -0,0 +1,51 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/helpers/validatordiag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ validator.Int64 = divisorValidator{}
+
+type divisorValidator struct {
+	dividend path.Path
+}
+
+func (v divisorValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("Value must be a divisor of %s", v.dividend)
+}
+
+func (v divisorValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v divisorValidator) ValidateInt64(ctx context.Context, request validator.Int64Request, response *validator.Int64Response) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() || request.ConfigValue.ValueInt64() < 1 {
+		return
+	}
+
+	var dividend types.Int64
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, v.dividend, &dividend)...)
+	if response.Diagnostics.HasError() || dividend.IsNull() || dividend.IsUnknown() {
+		return
+	}
+
+	if dividend.ValueInt64()%request.ConfigValue.ValueInt64() != 0 {
+		response.Diagnostics.Append(validatordiag.InvalidAttributeValueDiagnostic(
+			request.Path,
+			fmt.Sprintf("%s (%d) must be divisible by this value", v.dividend, dividend.ValueInt64()),
+			fmt.Sprintf("%d", request.ConfigValue.ValueInt64()),
+		))
+	}
+}
+
+// DivisorOf validates that the value of the attribute at the given path is divisible by this value
+func DivisorOf(dividend path.Path) validator.Int64 {
+	return divisorValidator{
+		dividend: dividend,
+	}
+}
@@ -0,0 +1,45 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/helpers/validatordiag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"net"
+)
+
+var _ validator.String = ipAddressValidator{}
+
+type ipAddressValidator struct {
+	version int
+}
+
+func (v ipAddressValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("Value must be a valid IPv%d address", v.version)
+}
+
+func (v ipAddressValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ipAddressValidator) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() || request.ConfigValue.ValueString() == "" {
+		return
+	}
+
+	ip := net.ParseIP(request.ConfigValue.ValueString())
+	if ip == nil || (v.version == 4) != (ip.To4() != nil) {
+		response.Diagnostics.Append(validatordiag.InvalidAttributeValueDiagnostic(
+			request.Path,
+			v.Description(ctx),
+			request.ConfigValue.ValueString(),
+		))
+	}
+}
+
+// IPAddress validates that the value is an IPv4 or IPv6 address, depending on version
+func IPAddress(version int) validator.String {
+	return ipAddressValidator{
+		version: version,
+	}
+}
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"context"
+	"github.com/previder/previder-go-sdk/client"
+	"sync"
+	"time"
+)
+
+// The SDK does not know the CPU topology yet and only sends assigned addresses on create, so the virtual machine
+// endpoints are called directly to send and read the number of sockets and the assigned addresses
+const virtualMachinePath = "v2/iaas/virtualmachine"
+
+type VirtualMachineExt struct {
+	client.VirtualMachineExt
+	// CpuSockets is nil when the API does not report the number of sockets
+	CpuSockets *int `json:"cpuSockets,omitempty"`
+}
+
+// Sockets returns the number of CPU sockets, a virtual machine without reported sockets has one socket
+func (vm *VirtualMachineExt) Sockets() int {
+	if vm.CpuSockets == nil || *vm.CpuSockets < 1 {
+		return 1
+	}
+	return *vm.CpuSockets
+}
+
+type VirtualMachineCreate struct {
+	client.VirtualMachineCreate
+	CpuSockets int `json:"cpuSockets,omitempty"`
+}
+
+type VirtualMachineUpdate struct {
+	client.VirtualMachineUpdate
+	CpuSockets        int                      `json:"cpuSockets,omitempty"`
+	NetworkInterfaces []NetworkInterfaceUpdate `json:"networkInterfaces"`
+}
+
+type NetworkInterfaceUpdate struct {
+	client.NetworkInterfaceUpdate
+	// AssignedAddresses is always sent, an empty list removes the assigned addresses
+	AssignedAddresses []string `json:"assignedAddresses"`
+}
+
+func GetVirtualMachine(baseClient *client.PreviderClient, id string) (*VirtualMachineExt, error) {
+	vm := new(VirtualMachineExt)
+	err := baseClient.Get(virtualMachinePath+"/"+id, vm, nil)
+	return vm, err
+}
+
+func CreateVirtualMachine(baseClient *client.PreviderClient, vm *VirtualMachineCreate) (*client.VirtualMachineTask, error) {
+	task := new(client.VirtualMachineTask)
+	err := baseClient.Post(virtualMachinePath, vm, task)
+	return task, err
+}
+
+func UpdateVirtualMachine(baseClient *client.PreviderClient, id string, vm *VirtualMachineUpdate) (*client.VirtualMachineTask, error) {
+	task := new(client.VirtualMachineTask)
+	err := baseClient.Put(virtualMachinePath+"/"+id, vm, task)
+	return task, err
+}
+
+// NewVirtualMachineUpdate returns an update that keeps the virtual machine as it is, including all disks and network
+// interfaces. It is used by resources that change a single part of a virtual machine.
+func NewVirtualMachineUpdate(vm *VirtualMachineExt) *VirtualMachineUpdate {
+	update := &VirtualMachineUpdate{}
+	update.Name = vm.Name
+	update.ComputeCluster = vm.ComputeCluster
+	update.Group = vm.Group
+	update.CpuCores = vm.CpuCores
+	if vm.CpuSockets != nil {
+		update.CpuSockets = *vm.CpuSockets
+	}
+	update.Memory = vm.Memory
+	update.Tags = vm.Tags
+	update.Flavor = vm.Flavor
+	update.TerminationProtectionEnabled = vm.TerminationProtectionEnabled
+	update.MarkedAsTemplate = vm.MarkedAsTemplate
+
+	for _, disk := range vm.Disks {
+		update.Disks = append(update.Disks, client.DiskUpdate{
+			Id:    disk.Id,
+			Size:  disk.Size,
+			Uuid:  disk.Uuid,
+			Label: disk.Label,
+		})
+	}
+	for _, networkInterface := range vm.NetworkInterfaces {
+		update.NetworkInterfaces = append(update.NetworkInterfaces, NetworkInterfaceUpdate{
+			NetworkInterfaceUpdate: client.NetworkInterfaceUpdate{
+				Id:        networkInterface.Id,
+				Network:   networkInterface.Network,
+				Connected: networkInterface.Connected,
+				Label:     networkInterface.Label,
+			},
+			AssignedAddresses: networkInterface.AssignedAddresses,
+		})
+	}
+
+	return update
+}
+
+var virtualMachineLocks sync.Map
+
+// LockVirtualMachine serializes changes to one virtual machine, resources that update a part of a virtual machine
+// read and write the whole machine. The returned function releases the lock.
+func LockVirtualMachine(id string) func() {
+	lock, _ := virtualMachineLocks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
+
+// ChangeVirtualMachine applies a change to one part of a virtual machine. The machine is locked, read, changed and
+// written back, the updated machine is returned when the task has finished.
+func ChangeVirtualMachine(ctx context.Context, baseClient *client.PreviderClient, id string, change func(update *VirtualMachineUpdate) error) (*VirtualMachineExt, error) {
+	unlock := LockVirtualMachine(id)
+	defer unlock()
+
+	vm, err := GetVirtualMachine(baseClient, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := NewVirtualMachineUpdate(vm)
+	err = change(update)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := UpdateVirtualMachine(baseClient, id, update)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = CheckTask(ctx, baseClient, task.Id, 5*time.Minute, VirtualMachineState(baseClient, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return GetVirtualMachine(baseClient, id)
+}
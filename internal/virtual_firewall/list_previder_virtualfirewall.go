@@ -0,0 +1,100 @@
+package virtual_firewall
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+var _ list.ListResource = (*listResourceImpl)(nil)
+var _ list.ListResourceWithConfigure = (*listResourceImpl)(nil)
+
+type listResourceImpl struct {
+	client *client.PreviderClient
+}
+
+type listConfigData struct {
+	Query   types.String `tfsdk:"query"`
+	Name    types.String `tfsdk:"name"`
+	Group   types.String `tfsdk:"group"`
+	Network types.String `tfsdk:"network"`
+}
+
+func NewListResource() list.ListResource {
+	return &listResourceImpl{}
+}
+
+func (r *listResourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func (r *listResourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, _, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *listResourceImpl) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Optional:    true,
+				Description: "Search query passed to the Previder API",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual firewalls with exactly this name",
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual firewalls in this group, by ObjectId or name",
+			},
+			"network": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual firewalls connected to this network, by ObjectId or name",
+			},
+		},
+	}
+}
+
+func (r *listResourceImpl) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	util.ListResults(ctx, req, stream, func(config listConfigData) util.ListObjects[client.VirtualFirewall] {
+		return util.ListObjects[client.VirtualFirewall]{
+			ObjectType: "Virtual Firewalls",
+			Page:       r.client.VirtualFirewall.Page,
+			Query:      config.Query,
+			Id:         func(firewall client.VirtualFirewall) string { return firewall.Id },
+			Name:       func(firewall client.VirtualFirewall) string { return firewall.Name },
+			Matches: func(firewall client.VirtualFirewall) bool {
+				return util.MatchesFilter(config.Name, firewall.Name) &&
+					util.MatchesFilter(config.Group, firewall.Group, firewall.GroupName) &&
+					util.MatchesFilter(config.Network, firewall.Network, firewall.NetworkName)
+			},
+			Resource: func(id string) (any, diag.Diagnostics) {
+				var data resourceData
+				var diags diag.Diagnostics
+				virtualFirewall, err := r.client.VirtualFirewall.Get(id)
+				if err != nil {
+					diags.AddError("Error while listing Virtual Firewalls", fmt.Sprintf("Virtual Firewall %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				rules, err := getAllNatRules(r.client, id)
+				if err != nil {
+					diags.AddError("Error while listing Virtual Firewalls", fmt.Sprintf("NAT rules of Virtual Firewall %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				diags.Append(populateResourceData(&data, virtualFirewall, rules, nil)...)
+				return &data, diags
+			},
+		}
+	})
+}
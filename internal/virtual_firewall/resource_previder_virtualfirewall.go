@@ -12,10 +12,10 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
 	"github.com/previder/terraform-provider-previder/internal/util/sorters"
-	"log"
 	"net"
 	"strings"
 	"time"
@@ -25,13 +25,20 @@ import (
 )
 
 const ResourceType = "previder_virtual_firewall"
+const logSubsystem = "previder.virtual_firewall"
+
+var stateUpgrades []util.StateUpgrade
 
 var _ resource.Resource = (*resourceImpl)(nil)
 var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
 var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithIdentity = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
 
 type resourceImpl struct {
-	client *client.PreviderClient
+	client         *client.PreviderClient
+	customer       string
+	customerClient util.CustomerClient
 }
 
 func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -42,16 +49,18 @@ func NewResource() resource.Resource {
 	return &resourceImpl{}
 }
 
-func (r *resourceImpl) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	var newDiags diag.Diagnostics
-	r.client, newDiags = util.ConfigureClient(req.ProviderData)
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
 	resp.Diagnostics.Append(newDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.customerClient = util.ConfigureCustomerClient(req.ProviderData)
 }
 
 func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
 	resp.Schema.Attributes = map[string]schema.Attribute{
 		"id": schema.StringAttribute{
 			MarkdownDescription: "ID of the Virtual Firewall",
@@ -191,7 +200,16 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 	}
 }
 
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = util.IdentitySchema()
+}
+
 func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, data resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -208,7 +226,18 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 		}
 	}
 
-	rules, err := r.getAllNatRules(virtualFirewall.Id)
+	pending, diags := util.GetPendingCreate(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if pending != nil {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Checking interrupted virtual firewall create", map[string]any{util.LogFieldId: pending.Id, util.LogFieldState: virtualFirewall.State})
+		if virtualFirewall.State == "READY" {
+			resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
+		} else {
+			resp.Diagnostics.AddWarning("Virtual firewall not ready", fmt.Sprintf("Virtual Firewall (%s) is still being created (%s), it is checked again on the next refresh", pending.Id, virtualFirewall.State))
+		}
+	}
+
+	rules, err := getAllNatRules(r.client, virtualFirewall.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error while updating Virtual Firewall", err.Error())
 		return
@@ -217,9 +246,11 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 	populateResourceData(&data, virtualFirewall, rules, &state)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var plan, data resourceData
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
@@ -286,11 +317,28 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	err = waitForVirtualFirewallState(r.client, plan.Id, "READY")
+	// The firewall exists from now on, an interrupted create is checked by Read
+	resp.Diagnostics.Append(util.SetPendingCreate(ctx, resp.Private, util.PendingCreate{Id: plan.Id.ValueString()})...)
+
+	err = waitForVirtualFirewallState(ctx, r.client, plan.Id, "READY")
 	if err != nil {
-		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error waiting for Virtual Firewall (%s) to become ready: %s", data.Id, err))
+		resp.Diagnostics.Append(util.PendingCreateDiagnostics(ctx, "Virtual Firewall", plan.Id.ValueString(), err)...)
+		// NAT rules are only added when the firewall is ready, the next apply adds them
+		pendingFirewall, err := r.client.VirtualFirewall.Get(plan.Id.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("ID from creation not found (%s): %s", plan.Id, err))
+			return
+		}
+		rules, err := getAllNatRules(r.client, pendingFirewall.Id)
+		if err != nil {
+			rules = &[]client.VirtualFirewallNatRule{}
+		}
+		populateResourceData(&data, pendingFirewall, rules, &plan)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 		return
 	}
+	resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
 
 	err = r.processNatRules(plan.Id.ValueString(), plan.NatRules, nil)
 	if err != nil {
@@ -304,7 +352,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	rules, err := r.getAllNatRules(createdFirewall.Id)
+	rules, err := getAllNatRules(r.client, createdFirewall.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error while updating Virtual Firewall", err.Error())
 		return
@@ -313,9 +361,11 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	populateResourceData(&data, createdFirewall, rules, &plan)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, plan, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -380,7 +430,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 	update.IcmpWanEnabled = plan.IcmpWanEnabled.ValueBool()
 	update.IcmpLanEnabled = plan.IcmpLanEnabled.ValueBool()
 
-	log.Printf("Updating Virtual Firewall %s", state.Id.ValueString())
+	tflog.SubsystemInfo(ctx, logSubsystem, "Updating Virtual Firewall", map[string]any{util.LogFieldId: state.Id.ValueString()})
 	err = r.client.VirtualFirewall.Update(state.Id.ValueString(), update)
 
 	if err != nil {
@@ -388,7 +438,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	err = waitForVirtualFirewallState(r.client, state.Id, "READY")
+	err = waitForVirtualFirewallState(ctx, r.client, state.Id, "READY")
 	if err != nil {
 		resp.Diagnostics.AddError("Error while waiting for Virtual Firewall to become ready", err.Error())
 		return
@@ -407,7 +457,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		resp.Diagnostics.AddError("Virtual Firewall not found", fmt.Sprintln("Virtual Firewall is not found after matched in list"))
 	}
 
-	rules, err := r.getAllNatRules(updatedFirewall.Id)
+	rules, err := getAllNatRules(r.client, updatedFirewall.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error while updating Virtual Firewall", err.Error())
 		return
@@ -416,10 +466,12 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 	populateResourceData(&data, updatedFirewall, rules, &state)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 
 }
 
 func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state resourceData
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -427,29 +479,67 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	log.Printf("[INFO] Deleting Virtual Firewall: %s", state.Id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Deleting Virtual Firewall", map[string]any{util.LogFieldId: state.Id.ValueString()})
 
 	err := r.client.VirtualFirewall.Delete(state.Id.ValueString())
 
-	err = waitForVirtualFirewallDeleted(r.client, state.Id)
+	err = waitForVirtualFirewallDeleted(ctx, r.client, state.Id)
 	if err != nil {
 		resp.Diagnostics.AddError("Error deleting Virtual Firewall: %s", err.Error())
 	}
 }
 
 func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
-	var virtualFirewall, _ = r.client.VirtualFirewall.Get(req.ID)
+	importId, newDiags := util.ImportIdFromRequest(ctx, req)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, customerId, newDiags := util.ResolveImportId(importId, r.listImportCandidates)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	importClient, newDiags := util.ImportClient(r.client, r.customer, r.customerClient, customerId)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualFirewall, err := importClient.VirtualFirewall.Get(id)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual Firewall not found", fmt.Sprintf("Error while importing Virtual Firewall (%s): %s", id, err))
+		return
+	}
 
-	rules, err := r.getAllNatRules(virtualFirewall.Id)
+	rules, err := getAllNatRules(importClient, virtualFirewall.Id)
 	if err != nil {
+		resp.Diagnostics.AddError("Error while importing Virtual Firewall", err.Error())
 		return
 	}
 
 	populateResourceData(&data, virtualFirewall, rules, nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
+
+}
 
+func (r *resourceImpl) listImportCandidates(query string) ([]util.ImportCandidate, error) {
+	firewalls, err := util.PageAll(r.client.VirtualFirewall.Page, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []util.ImportCandidate
+	for _, firewall := range firewalls {
+		candidates = append(candidates, util.ImportCandidate{Id: firewall.Id, Name: firewall.Name, Group: firewall.Group, GroupName: firewall.GroupName})
+	}
+	return candidates, nil
 }
 
 func (r *resourceImpl) validateNatRules(dataNatRules map[string]resourceDataNatRule) error {
@@ -512,7 +602,7 @@ func (r *resourceImpl) processNatRules(firewallId string, dataNatRules map[strin
 	}
 	// Cleanup old rules
 	if existingData != nil {
-		currentRules, err := r.getAllNatRules(firewallId)
+		currentRules, err := getAllNatRules(r.client, firewallId)
 		if err != nil {
 			return err
 		}
@@ -530,14 +620,14 @@ func (r *resourceImpl) processNatRules(firewallId string, dataNatRules map[strin
 	return nil
 }
 
-func (r *resourceImpl) getAllNatRules(id string) (*[]client.VirtualFirewallNatRule, error) {
+func getAllNatRules(baseClient *client.PreviderClient, id string) (*[]client.VirtualFirewallNatRule, error) {
 	var page client.PageRequest
 	page.Size = 100
 	page.Page = 0
 	page.Sort = "+description"
 	page.Query = ""
 
-	_, rules, err := r.client.VirtualFirewall.PageNatRules(id, page)
+	_, rules, err := baseClient.VirtualFirewall.PageNatRules(id, page)
 	if err != nil {
 		return nil, err
 	}
@@ -545,23 +635,24 @@ func (r *resourceImpl) getAllNatRules(id string) (*[]client.VirtualFirewallNatRu
 	return rules, nil
 }
 
-func waitForVirtualFirewallState(client *client.PreviderClient, id types.String, target string) error {
-	log.Printf("[INFO] Waiting for Virtual Firewall (%s) to have state %s", id, target)
+func waitForVirtualFirewallState(ctx context.Context, client *client.PreviderClient, id types.String, target string) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for Virtual Firewall to have state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldTarget: target})
 
 	backoffOperation := func() error {
 		cluster, err := client.VirtualFirewall.Get(id.ValueString())
 
 		if err != nil {
-			log.Printf("invalid Virtual Firewall id: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Virtual Firewall could not be fetched while waiting for state", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid Virtual Firewall id: %s", id))
 		}
+		tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for Virtual Firewall to become ready", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldState: cluster.State, util.LogFieldTarget: target})
 		if cluster.State != target {
 			return errors.New(fmt.Sprintf("Waiting for Virtual Firewall to become ready: %s (%s)", id, cluster.State))
 		}
 		return nil
 	}
 	// Max waiting time is 30 mins (should be max 10 mins for smaller clusters)
-	backoffConfig := backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 180)
+	backoffConfig := backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 180), ctx)
 
 	err := backoff.Retry(backoffOperation, backoffConfig)
 	if err != nil {
@@ -571,26 +662,24 @@ func waitForVirtualFirewallState(client *client.PreviderClient, id types.String,
 	return nil
 }
 
-func waitForVirtualFirewallDeleted(client *client.PreviderClient, id types.String) error {
+func waitForVirtualFirewallDeleted(ctx context.Context, client *client.PreviderClient, id types.String) error {
 	backoffOperation := func() error {
 		cluster, err := client.VirtualFirewall.Get(id.ValueString())
-		log.Printf("Fetching Virtual Firewall: %v", id)
 		if err != nil {
-			log.Printf("Error while fetching a Virtual Firewall")
 			if strings.Contains(err.Error(), "404") &&
 				strings.Contains(err.Error(), "not found") {
 				return nil
 			}
-			log.Printf("Virtual Firewall still exists, but got an error: %v", err)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Virtual Firewall still exists, but got an error", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("Virtual Firewall still exists, but got an error: %s", id.ValueString()))
 		}
 		if cluster.State == "PENDING_REMOVAL" {
-			log.Printf("Waiting for Virtual Firewall to be gone: %v", cluster.State)
+			tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for Virtual Firewall to be gone", map[string]any{util.LogFieldId: id.ValueString(), util.LogFieldState: cluster.State})
 			return errors.New(fmt.Sprintf("Waiting for Virtual Firewall to be gone: %s", id.ValueString()))
 		}
 		return nil
 	}
-	log.Printf("Waiting for Virtual Firewall deletion: %v", id)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for Virtual Firewall deletion", map[string]any{util.LogFieldId: id.ValueString()})
 	// Max waiting time is 20 mins
 	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*10), 120)
 
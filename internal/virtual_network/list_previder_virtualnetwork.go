@@ -0,0 +1,95 @@
+package virtual_network
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+var _ list.ListResource = (*listResourceImpl)(nil)
+var _ list.ListResourceWithConfigure = (*listResourceImpl)(nil)
+
+type listResourceImpl struct {
+	client *client.PreviderClient
+}
+
+type listConfigData struct {
+	Query types.String `tfsdk:"query"`
+	Name  types.String `tfsdk:"name"`
+	Group types.String `tfsdk:"group"`
+	Type  types.String `tfsdk:"type"`
+}
+
+func NewListResource() list.ListResource {
+	return &listResourceImpl{}
+}
+
+func (r *listResourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func (r *listResourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, _, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *listResourceImpl) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Optional:    true,
+				Description: "Search query passed to the Previder API",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual networks with exactly this name",
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual networks in this group, by ObjectId or name",
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual networks of this type, e.g. VLAN",
+			},
+		},
+	}
+}
+
+func (r *listResourceImpl) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	util.ListResults(ctx, req, stream, func(config listConfigData) util.ListObjects[client.VirtualNetwork] {
+		return util.ListObjects[client.VirtualNetwork]{
+			ObjectType: "Virtual Networks",
+			Page:       r.client.VirtualNetwork.Page,
+			Query:      config.Query,
+			Id:         func(network client.VirtualNetwork) string { return network.Id },
+			Name:       func(network client.VirtualNetwork) string { return network.Name },
+			Matches: func(network client.VirtualNetwork) bool {
+				return util.MatchesFilter(config.Name, network.Name) &&
+					util.MatchesFilter(config.Group, network.Group, network.GroupName) &&
+					util.MatchesFilter(config.Type, network.Type)
+			},
+			Resource: func(id string) (any, diag.Diagnostics) {
+				var data resourceData
+				var diags diag.Diagnostics
+				virtualNetwork, err := r.client.VirtualNetwork.Get(id)
+				if err != nil {
+					diags.AddError("Error while listing Virtual Networks", fmt.Sprintf("Virtual Network %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				diags.Append(populateResourceData(&data, virtualNetwork, nil)...)
+				return &data, diags
+			},
+		}
+	})
+}
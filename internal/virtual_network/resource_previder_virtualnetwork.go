@@ -11,21 +11,28 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
-	"log"
 	"strings"
 	"time"
 )
 
 const ResourceType = "previder_virtual_network"
+const logSubsystem = "previder.virtual_network"
+
+var stateUpgrades []util.StateUpgrade
 
 var _ resource.Resource = (*resourceImpl)(nil)
 var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
 var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithIdentity = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
 
 type resourceImpl struct {
-	client *client.PreviderClient
+	client         *client.PreviderClient
+	customer       string
+	customerClient util.CustomerClient
 }
 
 func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -36,17 +43,18 @@ func NewResource() resource.Resource {
 	return &resourceImpl{}
 }
 
-func (r *resourceImpl) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	var newDiags diag.Diagnostics
-	r.client, newDiags = util.ConfigureClient(req.ProviderData)
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
 	resp.Diagnostics.Append(newDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.customerClient = util.ConfigureCustomerClient(req.ProviderData)
 }
 
 func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
-
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
 	resp.Schema.Attributes = map[string]schema.Attribute{
 		"id": schema.StringAttribute{
 			MarkdownDescription: "ID of the virtual server",
@@ -74,7 +82,16 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 	}
 }
 
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = util.IdentitySchema()
+}
+
 func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var create client.VirtualNetworkUpdate
 	var plan, data resourceData
 
@@ -93,7 +110,12 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	_, _ = r.client.Task.WaitFor(task.Id, 5*time.Minute)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual network create task", map[string]any{util.LogFieldId: task.VirtualNetwork, util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 5*time.Minute, virtualNetworkState(r.client, task.VirtualNetwork))
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual Network not created", fmt.Sprintf("Error while creating Virtual Network (%s): %s", plan.Name.ValueString(), err))
+		return
+	}
 
 	network, err := r.client.VirtualNetwork.Get(task.VirtualNetwork)
 	if err != nil {
@@ -102,7 +124,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 
 	data.Id = types.StringValue(task.VirtualNetwork)
-	err = waitForVirtualNetworkState(*r.client, data.Id.ValueString(), client.VirtualNetworkStateReady)
+	err = waitForVirtualNetworkState(ctx, *r.client, data.Id.ValueString(), client.VirtualNetworkStateReady)
 	if err != nil {
 		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error waiting for Virtual Network (%s) to become ready: %s", data.Id, err))
 		return
@@ -110,12 +132,12 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 
 	populateResourceData(&data, network, &plan)
 
-	log.Printf("Searching for ID %s", data.Id.ValueString())
-
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -135,9 +157,11 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 
 	populateResourceData(&data, network, &state)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state, plan, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -164,7 +188,12 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		resp.Diagnostics.AddError("Error updating Virtual Network", fmt.Sprintf("Virtual Network has not been updated %s: %s", state.Name, err.Error()))
 		return
 	}
-	_, _ = r.client.Task.WaitFor(task.Id, 5*time.Minute)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual network update task", map[string]any{util.LogFieldId: state.Id.ValueString(), util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 5*time.Minute, virtualNetworkState(r.client, state.Id.ValueString()))
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual Network not updated", fmt.Sprintf("Error while updating Virtual Network (%s): %s", plan.Name.ValueString(), err))
+		return
+	}
 
 	vm, err = r.client.VirtualNetwork.Get(state.Id.ValueString())
 	if err != nil {
@@ -174,9 +203,11 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	populateResourceData(&data, vm, &plan)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -195,7 +226,8 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	_, err = r.client.Task.WaitFor(task.Id, 30*time.Minute)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual network delete task", map[string]any{util.LogFieldId: state.Id.ValueString(), util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 30*time.Minute, virtualNetworkState(r.client, state.Id.ValueString()))
 	if err != nil {
 		resp.Diagnostics.AddError("Virtual network not deleted", fmt.Sprintf("Virtual network is not deleted: %s", err.Error()))
 		return
@@ -204,23 +236,62 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 }
 
 func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
-	var network, _ = r.client.VirtualNetwork.Get(req.ID)
+	importId, newDiags := util.ImportIdFromRequest(ctx, req)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, customerId, newDiags := util.ResolveImportId(importId, r.listImportCandidates)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	importClient, newDiags := util.ImportClient(r.client, r.customer, r.customerClient, customerId)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	network, err := importClient.VirtualNetwork.Get(id)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual network not found", fmt.Sprintf("Error while importing Virtual Network (%s): %s", id, err))
+		return
+	}
 
 	populateResourceData(&data, network, nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 
 }
 
-func waitForVirtualNetworkState(client client.PreviderClient, id string, target string) error {
+func (r *resourceImpl) listImportCandidates(query string) ([]util.ImportCandidate, error) {
+	networks, err := util.PageAll(r.client.VirtualNetwork.Page, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []util.ImportCandidate
+	for _, network := range networks {
+		candidates = append(candidates, util.ImportCandidate{Id: network.Id, Name: network.Name, Group: network.Group, GroupName: network.GroupName})
+	}
+	return candidates, nil
+}
+
+func waitForVirtualNetworkState(ctx context.Context, client client.PreviderClient, id string, target string) error {
 
 	backoffOperation := func() error {
 		network, err := client.VirtualNetwork.Get(id)
 
 		if err != nil {
+			tflog.SubsystemDebug(ctx, logSubsystem, "Virtual network could not be fetched while waiting for state", map[string]any{util.LogFieldId: id, util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid Virtual Network id: %s", id))
 		}
+		tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for virtual network to become ready", map[string]any{util.LogFieldId: id, util.LogFieldState: network.State, util.LogFieldTarget: target})
 		if network.State != target {
 			return errors.New(fmt.Sprintf("Waiting for Virtual Network to become ready: %s", id))
 		}
@@ -236,3 +307,14 @@ func waitForVirtualNetworkState(client client.PreviderClient, id string, target
 
 	return nil
 }
+
+// virtualNetworkState returns the state of a virtual network for a task failure
+func virtualNetworkState(baseClient *client.PreviderClient, id string) func() string {
+	return func() string {
+		network, err := baseClient.VirtualNetwork.Get(id)
+		if err != nil {
+			return ""
+		}
+		return network.State
+	}
+}
@@ -0,0 +1,171 @@
+package virtual_server
+
+import (
+	"context"
+	"fmt"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"net"
+	"slices"
+	"time"
+)
+
+const defaultGuestIpTimeout = 10 * time.Minute
+
+// guestIpWait is the parsed wait_for_guest_ip attribute
+type guestIpWait struct {
+	Enabled bool
+	Labels  []string
+	Timeout time.Duration
+}
+
+// parseWaitForGuestIp reads wait_for_guest_ip, which is either a bool or an object with network_interfaces and
+// timeout. The returned bool is false when the value is not known yet.
+func parseWaitForGuestIp(value types.Dynamic) (guestIpWait, bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	attributePath := path.Root("wait_for_guest_ip")
+	wait := guestIpWait{Timeout: defaultGuestIpTimeout}
+
+	if value.IsNull() || value.IsUnderlyingValueNull() {
+		return wait, true, diags
+	}
+	if value.IsUnknown() || value.IsUnderlyingValueUnknown() {
+		return wait, false, diags
+	}
+
+	switch underlying := value.UnderlyingValue().(type) {
+	case types.Bool:
+		wait.Enabled = underlying.ValueBool()
+		return wait, true, diags
+	case types.Object:
+		wait.Enabled = true
+		for name, attribute := range underlying.Attributes() {
+			if attribute.IsUnknown() {
+				return wait, false, diags
+			}
+			if attribute.IsNull() {
+				continue
+			}
+			switch name {
+			case "network_interfaces":
+				labels, ok := stringElements(attribute)
+				if !ok {
+					diags.AddAttributeError(attributePath.AtName(name), "Invalid wait_for_guest_ip", "network_interfaces must be a list of network interface labels")
+					continue
+				}
+				if labels == nil {
+					return wait, false, diags
+				}
+				wait.Labels = labels
+			case "timeout":
+				timeout, ok := attribute.(types.String)
+				if !ok {
+					diags.AddAttributeError(attributePath.AtName(name), "Invalid wait_for_guest_ip", "timeout must be a duration like 10m")
+					continue
+				}
+				duration, err := time.ParseDuration(timeout.ValueString())
+				if err != nil || duration <= 0 {
+					diags.AddAttributeError(attributePath.AtName(name), "Invalid wait_for_guest_ip", fmt.Sprintf("timeout %q must be a positive duration like 30s, 10m or 1h", timeout.ValueString()))
+					continue
+				}
+				wait.Timeout = duration
+			default:
+				diags.AddAttributeError(attributePath, "Invalid wait_for_guest_ip", fmt.Sprintf("Unsupported attribute %s, expected network_interfaces or timeout", name))
+			}
+		}
+		return wait, true, diags
+	}
+
+	diags.AddAttributeError(attributePath, "Invalid wait_for_guest_ip", "Expected true, false or an object with network_interfaces and timeout")
+	return wait, true, diags
+}
+
+// stringElements returns the strings of a list, tuple or set value. The slice is nil when an element is unknown.
+func stringElements(value attr.Value) ([]string, bool) {
+	var elements []attr.Value
+	switch collection := value.(type) {
+	case types.List:
+		elements = collection.Elements()
+	case types.Tuple:
+		elements = collection.Elements()
+	case types.Set:
+		elements = collection.Elements()
+	default:
+		return nil, false
+	}
+
+	result := []string{}
+	for _, element := range elements {
+		label, ok := element.(types.String)
+		if !ok || label.IsNull() {
+			return nil, false
+		}
+		if label.IsUnknown() {
+			return nil, true
+		}
+		result = append(result, label.ValueString())
+	}
+	return result, true
+}
+
+// usableAddress returns the parsed address when it can be used to reach the guest, link-local and loopback addresses
+// are not usable
+func usableAddress(address string) (net.IP, bool) {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return nil, false
+	}
+	return ip, true
+}
+
+func hasUsableAddress(networkInterface client.NetworkInterface) bool {
+	for _, address := range append(networkInterface.AssignedAddresses, networkInterface.DiscoveredAddresses...) {
+		if _, ok := usableAddress(address); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForGuestIp waits until the selected network interfaces have a usable address, all connected network interfaces
+// are selected when labels is empty
+func waitForGuestIp(ctx context.Context, baseClient *client.PreviderClient, id string, labels []string, timeout time.Duration) error {
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for guest IP address", map[string]any{util.LogFieldId: id, "network_interfaces": labels, "timeout": timeout.String()})
+
+	backoffOperation := func() error {
+		vm, err := baseClient.VirtualServer.Get(id)
+		if err != nil {
+			return fmt.Errorf("invalid Virtual Server id: %s", id)
+		}
+
+		var waiting []string
+		for _, networkInterface := range vm.NetworkInterfaces {
+			selected := slices.Contains(labels, networkInterface.Label) || (len(labels) == 0 && networkInterface.Connected)
+			if selected && !hasUsableAddress(networkInterface) {
+				waiting = append(waiting, networkInterface.Label)
+			}
+		}
+		for _, label := range labels {
+			if !slices.ContainsFunc(vm.NetworkInterfaces, func(networkInterface client.NetworkInterface) bool { return networkInterface.Label == label }) {
+				return backoff.Permanent(fmt.Errorf("virtual server has no network interface with label %s", label))
+			}
+		}
+
+		if len(waiting) > 0 {
+			tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for guest IP address", map[string]any{util.LogFieldId: id, "network_interfaces": waiting})
+			return fmt.Errorf("no usable address on network interfaces %v of Virtual Server %s", waiting, id)
+		}
+		return nil
+	}
+
+	interval := 5 * time.Second
+	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(interval), uint64(timeout/interval))
+
+	return backoff.Retry(backoffOperation, backoff.WithContext(backoffConfig, ctx))
+}
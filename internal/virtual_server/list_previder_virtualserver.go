@@ -0,0 +1,102 @@
+package virtual_server
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+var _ list.ListResource = (*listResourceImpl)(nil)
+var _ list.ListResourceWithConfigure = (*listResourceImpl)(nil)
+
+type listResourceImpl struct {
+	client *client.PreviderClient
+}
+
+type listConfigData struct {
+	Query          types.String `tfsdk:"query"`
+	Name           types.String `tfsdk:"name"`
+	Group          types.String `tfsdk:"group"`
+	ComputeCluster types.String `tfsdk:"compute_cluster"`
+	State          types.String `tfsdk:"state"`
+}
+
+func NewListResource() list.ListResource {
+	return &listResourceImpl{}
+}
+
+func (r *listResourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func (r *listResourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, _, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *listResourceImpl) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Optional:    true,
+				Description: "Search query passed to the Previder API",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual servers with exactly this name",
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual servers in this group, by ObjectId or name",
+			},
+			"compute_cluster": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual servers in this compute cluster",
+			},
+			"state": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list virtual servers in this state, e.g. POWEREDON",
+			},
+		},
+	}
+}
+
+func (r *listResourceImpl) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	util.ListResults(ctx, req, stream, func(config listConfigData) util.ListObjects[client.VirtualMachine] {
+		return util.ListObjects[client.VirtualMachine]{
+			ObjectType: "Virtual Servers",
+			Page:       r.client.VirtualServer.Page,
+			Query:      config.Query,
+			Id:         func(vm client.VirtualMachine) string { return vm.Id },
+			Name:       func(vm client.VirtualMachine) string { return vm.Name },
+			Matches: func(vm client.VirtualMachine) bool {
+				return util.MatchesFilter(config.Name, vm.Name) &&
+					util.MatchesFilter(config.Group, vm.Group, vm.GroupName) &&
+					util.MatchesFilter(config.ComputeCluster, vm.ComputeCluster) &&
+					util.MatchesFilter(config.State, vm.State)
+			},
+			Resource: func(id string) (any, diag.Diagnostics) {
+				var data resourceData
+				var diags diag.Diagnostics
+				virtualMachine, err := util.GetVirtualMachine(r.client, id)
+				if err != nil {
+					diags.AddError("Error while listing Virtual Servers", fmt.Sprintf("Virtual Server %s could not be fetched: %s", id, err))
+					return nil, diags
+				}
+				diags.Append(populateResourceData(ctx, &data, virtualMachine, nil)...)
+				data.Source = types.StringValue("")
+				return &data, diags
+			},
+		}
+	})
+}
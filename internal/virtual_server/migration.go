@@ -0,0 +1,59 @@
+package virtual_server
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"strings"
+	"time"
+)
+
+const defaultMigrationTimeout = 60 * time.Minute
+
+// validateComputeCluster returns an error when the compute cluster does not exist
+func validateComputeCluster(baseClient *client.PreviderClient, name string) error {
+	computeClusters, err := baseClient.VirtualServer.ComputeClusterList()
+	if err != nil {
+		return fmt.Errorf("compute clusters could not be listed: %w", err)
+	}
+
+	var names []string
+	for _, computeCluster := range *computeClusters {
+		if computeCluster.Name == name {
+			return nil
+		}
+		names = append(names, computeCluster.Name)
+	}
+	return fmt.Errorf("compute cluster %s does not exist, available compute clusters are %s", name, strings.Join(names, ", "))
+}
+
+// migrateVirtualMachine moves a virtual machine to another compute cluster. Only the compute cluster is changed, so a
+// failed migration leaves the rest of the virtual machine untouched.
+func migrateVirtualMachine(ctx context.Context, baseClient *client.PreviderClient, vm *util.VirtualMachineExt, target string, timeout time.Duration) error {
+	err := validateComputeCluster(baseClient, target)
+	if err != nil {
+		return err
+	}
+
+	update := util.NewVirtualMachineUpdate(vm)
+	update.ComputeCluster = target
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Migrating virtual server", map[string]any{util.LogFieldId: vm.Id, "compute_cluster": vm.ComputeCluster, "target_compute_cluster": target, "timeout": timeout.String()})
+	task, err := util.UpdateVirtualMachine(baseClient, vm.Id, update)
+	if err != nil {
+		return err
+	}
+
+	progress := func(migrationTask *client.Task) {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Migration in progress", map[string]any{util.LogFieldId: vm.Id, util.LogFieldTaskId: task.Id, "progress": migrationTask.Progress})
+	}
+	_, err = util.CheckTaskProgress(ctx, baseClient, task.Id, timeout, util.VirtualMachineState(baseClient, vm.Id), progress)
+	if err != nil {
+		return fmt.Errorf("migration to compute cluster %s failed: %w", target, err)
+	}
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Virtual server migrated", map[string]any{util.LogFieldId: vm.Id, "compute_cluster": target})
+	return nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
 	"net"
+	"slices"
 )
 
 type resourceData struct {
@@ -14,27 +14,40 @@ type resourceData struct {
 	Name                  types.String                            `tfsdk:"name"`
 	Group                 types.String                            `tfsdk:"group"`
 	ComputeCluster        types.String                            `tfsdk:"compute_cluster"`
+	MigrationTimeout      types.String                            `tfsdk:"migration_timeout"`
 	CpuCores              types.Int64                             `tfsdk:"cpu_cores"`
 	CpuSockets            types.Int64                             `tfsdk:"cpu_sockets"`
+	ResizePolicy          types.String                            `tfsdk:"resize_policy"`
 	Memory                types.Int64                             `tfsdk:"memory"`
 	Template              types.String                            `tfsdk:"template"`
 	GuestId               types.String                            `tfsdk:"guest_id"`
 	Source                types.String                            `tfsdk:"source"`
 	State                 types.String                            `tfsdk:"state"`
+	PowerState            types.String                            `tfsdk:"power_state"`
 	Tags                  []types.String                          `tfsdk:"tags"`
 	Disks                 map[string]resourceDataDisk             `tfsdk:"disks"`
+	DropSourceDisks       []types.String                          `tfsdk:"drop_source_disks"`
 	NetworkInterfaces     map[string]resourceDataNetworkInterface `tfsdk:"network_interfaces"`
 	TerminationProtection types.Bool                              `tfsdk:"termination_protection"`
+	AllowDiskDeletion     types.Bool                              `tfsdk:"allow_disk_deletion"`
 	UserData              types.String                            `tfsdk:"user_data"`
+	UserDataBase64        types.String                            `tfsdk:"user_data_base64"`
+	UserDataGzip          types.String                            `tfsdk:"user_data_gzip"`
+	UserDataHash          types.String                            `tfsdk:"user_data_hash"`
+	UserDataWo            types.String                            `tfsdk:"user_data_wo"`
+	UserDataWoVersion     types.Int64                             `tfsdk:"user_data_wo_version"`
+	WaitForGuestIp        types.Dynamic                           `tfsdk:"wait_for_guest_ip"`
 	ProvisioningType      types.String                            `tfsdk:"provisioning_type"`
 	InitialPassword       types.String                            `tfsdk:"initial_password"`
+	StoreInitialPassword  types.Bool                              `tfsdk:"store_initial_password"`
 }
 
 type resourceDataDisk struct {
-	Id    types.String `tfsdk:"id"`
-	Size  types.Int64  `tfsdk:"size"`
-	Uuid  types.String `tfsdk:"uuid"`
-	Label types.String `tfsdk:"label"`
+	Id         types.String `tfsdk:"id"`
+	Size       types.Int64  `tfsdk:"size"`
+	Uuid       types.String `tfsdk:"uuid"`
+	Label      types.String `tfsdk:"label"`
+	SourceDisk types.String `tfsdk:"source_disk"`
 }
 
 type resourceDataNetworkInterface struct {
@@ -42,6 +55,8 @@ type resourceDataNetworkInterface struct {
 	Network             types.String `tfsdk:"network"`
 	Connected           types.Bool   `tfsdk:"connected"`
 	Label               types.String `tfsdk:"label"`
+	StaticIPv4Address   types.String `tfsdk:"static_ipv4_address"`
+	StaticIPv6Address   types.String `tfsdk:"static_ipv6_address"`
 	IPv4Address         types.String `tfsdk:"ipv4_address"`
 	IPv6Address         types.String `tfsdk:"ipv6_address"`
 	MACAddress          types.String `tfsdk:"mac_address"`
@@ -50,7 +65,7 @@ type resourceDataNetworkInterface struct {
 	Type                types.String `tfsdk:"type"`
 }
 
-func populateResourceData(ctx context.Context, data *resourceData, in *client.VirtualMachineExt, plan *resourceData) diag.Diagnostics {
+func populateResourceData(ctx context.Context, data *resourceData, in *util.VirtualMachineExt, plan *resourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 	var newDiags diag.Diagnostics
 
@@ -67,7 +82,13 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 	}
 	data.ComputeCluster = types.StringValue(in.ComputeCluster)
 	data.CpuCores = types.Int64Value(int64(in.CpuCores))
+	data.CpuSockets = types.Int64Value(int64(in.Sockets()))
 	data.Memory = types.Int64Value(int64(in.Memory))
+	if plan.ResizePolicy.IsNull() || plan.ResizePolicy.IsUnknown() {
+		data.ResizePolicy = types.StringValue(resizePolicyGracefulThenForce)
+	} else {
+		data.ResizePolicy = plan.ResizePolicy
+	}
 
 	if len(in.Template) == 0 {
 		data.Template = types.StringValue("")
@@ -80,12 +101,26 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 		data.GuestId = types.StringValue(in.GuestId)
 	}
 
-	data.InitialPassword = types.StringValue(in.InitialPassword)
+	// Without a plan, like on import, the password is stored as before
+	data.StoreInitialPassword = types.BoolValue(plan.StoreInitialPassword.IsNull() || plan.StoreInitialPassword.ValueBool())
+	if data.StoreInitialPassword.ValueBool() {
+		data.InitialPassword = types.StringValue(in.InitialPassword)
+	} else {
+		data.InitialPassword = types.StringNull()
+	}
 
 	data.State = types.StringValue(in.State)
-	var readTags = make([]types.String, len(in.Tags))
-	for i, v := range in.Tags {
-		readTags[i] = types.StringValue(v)
+	if powerState, ok := powerStateFromVmState(in.State); ok {
+		data.PowerState = types.StringValue(powerState)
+	} else if !plan.PowerState.IsNull() && !plan.PowerState.IsUnknown() {
+		data.PowerState = plan.PowerState
+	} else {
+		data.PowerState = types.StringValue("")
+	}
+	// Tags are sorted so the order of the API never shows as a change
+	var readTags = make([]types.String, 0, len(in.Tags))
+	for _, v := range slices.Compact(slices.Sorted(slices.Values(in.Tags))) {
+		readTags = append(readTags, types.StringValue(v))
 	}
 	data.Tags = readTags
 
@@ -96,9 +131,12 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 			Size:  types.Int64Value(int64(v.Size)),
 			Label: types.StringValue(v.Label),
 			Uuid:  types.StringValue(v.Uuid),
+			// The source disk is only used when cloning and is not returned by the API
+			SourceDisk: plan.Disks[v.Label].SourceDisk,
 		}
 	}
 	data.Disks = readDisks
+	data.DropSourceDisks = plan.DropSourceDisks
 
 	var readNetworkInterfaces = make(map[string]resourceDataNetworkInterface)
 	for _, v := range in.NetworkInterfaces {
@@ -110,6 +148,8 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 		}
 
 		if plannedNetworkInterface, ok := plan.NetworkInterfaces[v.Label]; ok {
+			readNetworkInterface.StaticIPv4Address = plannedNetworkInterface.StaticIPv4Address
+			readNetworkInterface.StaticIPv6Address = plannedNetworkInterface.StaticIPv6Address
 			if util.IsValidObjectId(plannedNetworkInterface.Network.ValueString()) {
 				readNetworkInterface.Network = types.StringValue(v.Network)
 			} else {
@@ -131,6 +171,17 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 				}
 			}
 		}
+		// Without an assigned address, the address reported by the guest is used
+		for _, av := range v.DiscoveredAddresses {
+			if ip, ok := usableAddress(av); ok {
+				if ip.To4() != nil && readNetworkInterface.IPv4Address.IsNull() {
+					readNetworkInterface.IPv4Address = types.StringValue(ip.String())
+				}
+				if ip.To4() == nil && readNetworkInterface.IPv6Address.IsNull() {
+					readNetworkInterface.IPv6Address = types.StringValue(ip.String())
+				}
+			}
+		}
 		if readNetworkInterface.IPv4Address.IsNull() {
 			readNetworkInterface.IPv4Address = types.StringValue("")
 		}
@@ -151,8 +202,22 @@ func populateResourceData(ctx context.Context, data *resourceData, in *client.Vi
 	data.NetworkInterfaces = readNetworkInterfaces
 
 	data.TerminationProtection = types.BoolValue(in.TerminationProtectionEnabled)
+	data.WaitForGuestIp = plan.WaitForGuestIp
+	data.MigrationTimeout = plan.MigrationTimeout
+
+	data.AllowDiskDeletion = types.BoolValue(plan.AllowDiskDeletion.ValueBool())
 
 	diags.Append(newDiags...)
 
 	return diags
 }
+
+// tagValues returns the sorted tags for the API
+func tagValues(tags []types.String) []string {
+	values := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		values = append(values, tag.ValueString())
+	}
+	slices.Sort(values)
+	return values
+}
@@ -0,0 +1,60 @@
+package virtual_server
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/previder/previder-go-sdk/client"
+)
+
+// Desired power states of a virtual server
+const (
+	powerStateOn        = "on"
+	powerStateOff       = "off"
+	powerStateSuspended = "suspended"
+)
+
+// The SDK has no constant for a suspended virtual server
+const vmStateSuspended = "SUSPENDED"
+
+// powerStateFromVmState maps the state of a virtual server to a power state, false is returned for transitional states
+func powerStateFromVmState(state string) (string, bool) {
+	switch state {
+	case client.VmStatePoweredOn:
+		return powerStateOn, true
+	case client.VmStatePoweredOff:
+		return powerStateOff, true
+	case vmStateSuspended:
+		return powerStateSuspended, true
+	}
+	return "", false
+}
+
+// applyPowerState powers on, shuts down or suspends the virtual server when it is not in the desired power state
+func applyPowerState(ctx context.Context, baseClient *client.PreviderClient, diags *diag.Diagnostics, id string, desired string) error {
+	vm, err := baseClient.VirtualServer.Get(id)
+	if err != nil {
+		diags.AddError("Virtual server not found", fmt.Sprintf("Error while getting Virtual Server %s: %s", id, err.Error()))
+		return err
+	}
+
+	current, _ := powerStateFromVmState(vm.State)
+	if current == desired {
+		return nil
+	}
+
+	switch desired {
+	case powerStateOn:
+		err = controlVirtualMachine(ctx, baseClient, id, client.VmActionPowerOn, client.VmStatePoweredOn)
+	case powerStateOff:
+		return gracefullyShutdownVirtualMachine(ctx, baseClient, diags, id, true)
+	case powerStateSuspended:
+		err = controlVirtualMachine(ctx, baseClient, id, client.VmActionSuspend, vmStateSuspended)
+	}
+	if err != nil {
+		diags.AddError("Virtual server power state not changed", fmt.Sprintf("Virtual Server %s could not be set to power state %s: %s", id, desired, err.Error()))
+		return err
+	}
+
+	return nil
+}
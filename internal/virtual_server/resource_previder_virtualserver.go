@@ -5,35 +5,64 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/util"
 	"github.com/previder/terraform-provider-previder/internal/util/sorters"
 	"github.com/previder/terraform-provider-previder/internal/util/validators"
-	"log"
+	"net"
+	"slices"
 	"strings"
 	"time"
 )
 
 const ResourceType = "previder_virtual_server"
+const logSubsystem = "previder.virtual_server"
+
+var stateUpgrades = []util.StateUpgrade{
+	// Version 1 changed tags from a list to a set
+	func(state map[string]any) error {
+		return util.ListToSet(state, "tags")
+	},
+}
+
+// Resize policies decide how a virtual server is shut down when cpu or memory changes
+const (
+	resizePolicyGracefulThenForce = "graceful_then_force"
+	resizePolicyGracefulOnly      = "graceful_only"
+	resizePolicyHotAdd            = "hot_add"
+	resizePolicyDeny              = "deny"
+)
 
 var _ resource.Resource = (*resourceImpl)(nil)
 var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
 var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithIdentity = (*resourceImpl)(nil)
+var _ resource.ResourceWithModifyPlan = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
 
 type resourceImpl struct {
-	client *client.PreviderClient
+	client         *client.PreviderClient
+	customer       string
+	customerClient util.CustomerClient
 }
 
 func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -44,16 +73,18 @@ func NewResource() resource.Resource {
 	return &resourceImpl{}
 }
 
-func (r *resourceImpl) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	var newDiags diag.Diagnostics
-	r.client, newDiags = util.ConfigureClient(req.ProviderData)
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
 	resp.Diagnostics.Append(newDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.customerClient = util.ConfigureCustomerClient(req.ProviderData)
 }
 
 func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
 	resp.Schema.Attributes = map[string]schema.Attribute{
 		"id": schema.StringAttribute{
 			MarkdownDescription: "ID of the virtual server",
@@ -66,7 +97,15 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			Required: true,
 		},
 		"compute_cluster": schema.StringAttribute{
-			Required: true,
+			MarkdownDescription: "Compute cluster of the virtual server, changing it migrates the virtual server to the other cluster",
+			Required:            true,
+		},
+		"migration_timeout": schema.StringAttribute{
+			MarkdownDescription: "Maximum time to wait for a migration to another compute cluster, like 60m",
+			Optional:            true,
+			Validators: []validator.String{
+				validators.Duration(),
+			},
 		},
 		"memory": schema.Int64Attribute{
 			Required: true,
@@ -75,7 +114,25 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 			Required: true,
 		},
 		"cpu_sockets": schema.Int64Attribute{
-			Optional: true,
+			MarkdownDescription: "Number of CPU sockets, `cpu_cores` must be divisible by the number of sockets",
+			Optional:            true,
+			Computed:            true,
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
+				validators.DivisorOf(path.Root("cpu_cores")),
+			},
+			PlanModifiers: []planmodifier.Int64{
+				int64planmodifier.UseStateForUnknown(),
+			},
+		},
+		"resize_policy": schema.StringAttribute{
+			MarkdownDescription: "How the virtual server is shut down to alter cpu or memory: `graceful_then_force`, `graceful_only`, `hot_add` or `deny`",
+			Optional:            true,
+			Computed:            true,
+			Default:             stringdefault.StaticString(resizePolicyGracefulThenForce),
+			Validators: []validator.String{
+				stringvalidator.OneOf(resizePolicyGracefulThenForce, resizePolicyGracefulOnly, resizePolicyHotAdd, resizePolicyDeny),
+			},
 		},
 		"group": schema.StringAttribute{
 			Optional: true,
@@ -113,9 +170,18 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 							stringplanmodifier.UseStateForUnknown(),
 						},
 					},
+					"source_disk": schema.StringAttribute{
+						MarkdownDescription: "Label or uuid of the disk of the `source` virtual server that is cloned to this disk",
+						Optional:            true,
+					},
 				},
 			},
 		},
+		"drop_source_disks": schema.SetAttribute{
+			MarkdownDescription: "Labels or uuids of the disks of the `source` virtual server that are not cloned",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
 		// Maps are always ordered by key by Terraform
 		"network_interfaces": schema.MapNestedAttribute{
 			Required: true,
@@ -162,14 +228,30 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 							listplanmodifier.UseStateForUnknown(),
 						},
 					},
+					"static_ipv4_address": schema.StringAttribute{
+						MarkdownDescription: "Static IPv4 address to assign, removing it removes the assignment",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.IPAddress(4),
+						},
+					},
+					"static_ipv6_address": schema.StringAttribute{
+						MarkdownDescription: "Static IPv6 address to assign, removing it removes the assignment",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.IPAddress(6),
+						},
+					},
 					"ipv4_address": schema.StringAttribute{
-						Computed: true,
+						MarkdownDescription: "First IPv4 address of the network interface, assigned or reported by the guest",
+						Computed:            true,
 						PlanModifiers: []planmodifier.String{
 							stringplanmodifier.UseStateForUnknown(),
 						},
 					},
 					"ipv6_address": schema.StringAttribute{
-						Computed: true,
+						MarkdownDescription: "First IPv6 address of the network interface, assigned or reported by the guest",
+						Computed:            true,
 						PlanModifiers: []planmodifier.String{
 							stringplanmodifier.UseStateForUnknown(),
 						},
@@ -212,6 +294,68 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 		},
 		"user_data": schema.StringAttribute{
 			Optional: true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRoot("user_data_base64"), path.MatchRoot("user_data_gzip"), path.MatchRoot("user_data_wo")),
+			},
+			PlanModifiers: []planmodifier.String{
+				userDataHashModifier{},
+			},
+		},
+		"user_data_base64": schema.StringAttribute{
+			MarkdownDescription: "Base64 encoded user data, like the output of `base64encode`",
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRoot("user_data"), path.MatchRoot("user_data_gzip"), path.MatchRoot("user_data_wo")),
+			},
+			PlanModifiers: []planmodifier.String{
+				userDataHashModifier{},
+			},
+		},
+		"user_data_gzip": schema.StringAttribute{
+			MarkdownDescription: "Base64 encoded gzip compressed user data, like the output of `base64gzip`",
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRoot("user_data"), path.MatchRoot("user_data_base64"), path.MatchRoot("user_data_wo")),
+			},
+			PlanModifiers: []planmodifier.String{
+				userDataHashModifier{},
+			},
+		},
+		"user_data_wo": schema.StringAttribute{
+			MarkdownDescription: "Write-only user data, it is sent to the API on create but never stored in the state",
+			Optional:            true,
+			Sensitive:           true,
+			WriteOnly:           true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRoot("user_data"), path.MatchRoot("user_data_base64"), path.MatchRoot("user_data_gzip")),
+			},
+		},
+		"user_data_wo_version": schema.Int64Attribute{
+			MarkdownDescription: "Version of `user_data_wo`, change it when the write-only user data changes",
+			Optional:            true,
+			Validators: []validator.Int64{
+				int64validator.AlsoRequires(path.MatchRoot("user_data_wo")),
+			},
+		},
+		"user_data_hash": schema.StringAttribute{
+			MarkdownDescription: "SHA-256 hash of the decoded user data, the API does not return the user data",
+			Computed:            true,
+		},
+		"wait_for_guest_ip": schema.DynamicAttribute{
+			MarkdownDescription: "Wait after create until the guest reports a usable IP address. `true` waits for all connected network interfaces, an object with `network_interfaces` (labels) and `timeout` (like 10m) selects the network interfaces",
+			Optional:            true,
+		},
+		"store_initial_password": schema.BoolAttribute{
+			MarkdownDescription: "Store the initial password in the state, when false `initial_password` stays empty",
+			Optional:            true,
+			Computed:            true,
+			Default:             booldefault.StaticBool(true),
+		},
+		"allow_disk_deletion": schema.BoolAttribute{
+			MarkdownDescription: "Allow disks to be deleted when they are removed from `disks`",
+			Optional:            true,
+			Computed:            true,
+			Default:             booldefault.StaticBool(false),
 		},
 		"termination_protection": schema.BoolAttribute{
 			Optional: true,
@@ -223,35 +367,158 @@ func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp
 		"state": schema.StringAttribute{
 			Computed: true,
 		},
+		"power_state": schema.StringAttribute{
+			MarkdownDescription: "Desired power state of the virtual server: `on`, `off` or `suspended`",
+			Optional:            true,
+			Computed:            true,
+			Validators: []validator.String{
+				stringvalidator.OneOf(powerStateOn, powerStateOff, powerStateSuspended),
+			},
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
 		"provisioning_type": schema.StringAttribute{
 			Computed: false,
 			Optional: true,
 		},
 		"initial_password": schema.StringAttribute{
-			Computed: true,
+			Computed:  true,
+			Sensitive: true,
 			PlanModifiers: []planmodifier.String{
 				stringplanmodifier.UseStateForUnknown(),
 			},
 		},
-		"tags": schema.ListAttribute{
-			Optional:    true,
-			Computed:    true,
-			ElementType: types.StringType,
-			Default: listdefault.StaticValue(
-				types.List(types.SetValueMust(
-					types.StringType,
-					[]attr.Value{},
-				)),
-			),
-			PlanModifiers: []planmodifier.List{
-				listplanmodifier.UseStateForUnknown(),
+		"tags": schema.SetAttribute{
+			MarkdownDescription: "Tags of the virtual server, the order of the tags is not significant",
+			Optional:            true,
+			Computed:            true,
+			ElementType:         types.StringType,
+			Default:             setdefault.StaticValue(types.SetValueMust(types.StringType, []attr.Value{})),
+			PlanModifiers: []planmodifier.Set{
+				setplanmodifier.UseStateForUnknown(),
 			},
 		},
 	}
 }
 
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = util.IdentitySchema()
+}
+
+func (r *resourceImpl) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	// The provider is not configured yet when its configuration depends on other resources, the checks against the API
+	// are skipped then
+	clientConfigured := r.client != nil
+
+	// The plan cannot be read while whole collections are unknown, the checks are done again during apply
+	var state, plan resourceData
+	var diags diag.Diagnostics
+	diags.Append(req.Plan.Get(ctx, &plan)...)
+	if diags.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateNetworkInterfaceAddresses(plan)...)
+	_, _, newDiags := parseWaitForGuestIp(plan.WaitForGuestIp)
+	resp.Diagnostics.Append(newDiags...)
+
+	if !plan.StoreInitialPassword.IsUnknown() && !plan.StoreInitialPassword.ValueBool() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("initial_password"), types.StringNull())...)
+	}
+
+	// Write-only values are only available in the config
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("user_data_wo"), &plan.UserDataWo)...)
+
+	userData, userDataPath := userDataSource(plan)
+	var planUserDataHash string
+	if !userData.IsUnknown() {
+		content, err := decodeUserData(plan)
+		if err == nil {
+			err = validateUserData(content)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(userDataPath, "Invalid user data", err.Error())
+		}
+		planUserDataHash = userDataHash(plan, content)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("user_data_hash"), planUserDataHash)...)
+	}
+
+	// The other checks compare with the current virtual server
+	if req.State.Raw.IsNull() {
+		if clientConfigured && !plan.Source.IsUnknown() && plan.Source.ValueString() != "" {
+			resp.Diagnostics.Append(r.validateSourceDisks(plan)...)
+		}
+		return
+	}
+	diags.Append(req.State.Get(ctx, &state)...)
+	if diags.HasError() {
+		return
+	}
+
+	if !userData.IsUnknown() && !state.UserDataHash.IsNull() && state.UserDataHash.ValueString() != planUserDataHash ||
+		!plan.UserDataWoVersion.Equal(state.UserDataWoVersion) {
+		resp.Diagnostics.AddAttributeWarning(userDataPath, "User data changed", "User data is only applied at the first boot of a virtual server, the change is stored but not applied to the running server")
+	}
+
+	if !plan.ComputeCluster.IsUnknown() && !plan.ComputeCluster.Equal(state.ComputeCluster) {
+		if clientConfigured {
+			if err := validateComputeCluster(r.client, plan.ComputeCluster.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("compute_cluster"), "Invalid compute cluster", err.Error())
+			}
+		}
+		resp.Diagnostics.AddAttributeWarning(path.Root("compute_cluster"), "Virtual server will be migrated", fmt.Sprintf("Virtual server %s will be migrated from compute cluster %s to %s", state.Name.ValueString(), state.ComputeCluster.ValueString(), plan.ComputeCluster.ValueString()))
+	}
+
+	if state.State.ValueString() != client.VmStatePoweredOff && resizeNeeded(state, plan) {
+		switch {
+		case plan.ResizePolicy.ValueString() == resizePolicyDeny:
+			resp.Diagnostics.AddAttributeError(path.Root("resize_policy"), "Virtual server resize denied", fmt.Sprintf("Virtual server %s has to be shut down to alter cpu cores, cpu sockets or memory quantity, but resize_policy is %s", state.Name.ValueString(), resizePolicyDeny))
+		case plan.ResizePolicy.ValueString() == resizePolicyHotAdd && canHotAdd(state, plan):
+			resp.Diagnostics.AddWarning("Virtual server may be shut down", fmt.Sprintf("Virtual server %s will be shut down to alter cpu cores or memory quantity when the change cannot be made while running", state.Name.ValueString()))
+		default:
+			resp.Diagnostics.AddWarning("Virtual server will be shut down", fmt.Sprintf("Virtual server %s will be shut down to alter cpu cores, cpu sockets or memory quantity", state.Name.ValueString()))
+		}
+	}
+
+	resp.Diagnostics.Append(validateDiskChanges(state, plan)...)
+
+	for _, k := range sorters.SortMapKeys(state.Disks) {
+		if _, ok := plan.Disks[k]; !ok && plan.AllowDiskDeletion.ValueBool() {
+			resp.Diagnostics.AddAttributeWarning(path.Root("disks").AtMapKey(k), "Disk will be deleted", fmt.Sprintf("Disk %s will be removed from virtual server %s, all data on the disk will be lost", k, state.Name.ValueString()))
+		}
+	}
+
+	for _, k := range sorters.SortMapKeys(state.NetworkInterfaces) {
+		plannedNetworkInterface, ok := plan.NetworkInterfaces[k]
+		if !ok {
+			resp.Diagnostics.AddAttributeWarning(path.Root("network_interfaces").AtMapKey(k), "Network interface will be removed", fmt.Sprintf("Network interface %s will be removed from virtual server %s", k, state.Name.ValueString()))
+			continue
+		}
+		if state.NetworkInterfaces[k].Connected.ValueBool() && !plannedNetworkInterface.Connected.IsUnknown() && !plannedNetworkInterface.Connected.ValueBool() {
+			resp.Diagnostics.AddAttributeWarning(path.Root("network_interfaces").AtMapKey(k), "Network interface will be disconnected", fmt.Sprintf("Network interface %s of virtual server %s will be disconnected", k, state.Name.ValueString()))
+		}
+		if addressesChanged(state.NetworkInterfaces[k], plannedNetworkInterface) {
+			networkInterfacePath := path.Root("network_interfaces").AtMapKey(k)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, networkInterfacePath.AtName("ipv4_address"), types.StringUnknown())...)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, networkInterfacePath.AtName("ipv6_address"), types.StringUnknown())...)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, networkInterfacePath.AtName("assigned_addresses"), types.ListUnknown(types.StringType))...)
+		}
+	}
+}
+
 func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var create client.VirtualMachineCreate
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var create util.VirtualMachineCreate
 	var plan, data resourceData
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -261,18 +528,32 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 
 	create.Name = plan.Name.ValueString()
 	create.CpuCores = int(plan.CpuCores.ValueInt64())
+	create.CpuSockets = int(plan.CpuSockets.ValueInt64())
 	create.Memory = uint64(plan.Memory.ValueInt64())
 	create.Group = plan.Group.ValueString()
-	create.UserData = plan.UserData.ValueString()
 	create.ProvisioningType = plan.ProvisioningType.ValueString()
-	create.PowerOnAfterClone = true
+	create.PowerOnAfterClone = plan.PowerState.IsUnknown() || plan.PowerState.IsNull() || plan.PowerState.ValueString() == powerStateOn
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("user_data_wo"), &plan.UserDataWo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	userData, err := decodeUserData(plan)
+	if err == nil {
+		err = validateUserData(userData)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid user data", err.Error())
+		return
+	}
+	create.UserData = userData
 
 	if !validVirtualServerSource(plan) {
 		resp.Diagnostics.AddError("Error while creating Virtual Server", fmt.Sprintf("Either template, guest_id or source has to be provided, only 1 value allowed"))
 		return
 	}
 
-	var existingDisks []client.Disk
+	var sourceDisks map[string]client.Disk
 	if !plan.Template.IsNull() && plan.Template.ValueString() != "" {
 		create.Template = plan.Template.ValueString()
 	} else if !plan.GuestId.IsNull() && plan.GuestId.ValueString() != "" {
@@ -281,11 +562,14 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 		create.SourceVirtualMachine = plan.Source.ValueString()
 		sourceVm, err := r.client.VirtualServer.Get(plan.Source.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError("Virtual server could not be found after creation", fmt.Sprintf("Error while creating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
+			resp.Diagnostics.AddError("Source virtual server not found", fmt.Sprintf("Error while getting source Virtual Server (%s): %s", plan.Source.ValueString(), err))
 			return
 		}
-		for _, existingDisk := range sourceVm.Disks {
-			existingDisks = append(existingDisks, existingDisk)
+		var newDiags diag.Diagnostics
+		sourceDisks, newDiags = mapSourceDisks(sourceVm.Disks, plan)
+		resp.Diagnostics.Append(newDiags...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
 	} else {
 		resp.Diagnostics.AddError("Error while creating Virtual Server", fmt.Sprintf("Either template, guest_id or source has to be provided"))
@@ -293,11 +577,7 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 	create.ComputeCluster = plan.ComputeCluster.ValueString()
 
-	var createTags []string
-	for _, tag := range plan.Tags {
-		createTags = append(createTags, tag.ValueString())
-	}
-	create.Tags = createTags
+	create.Tags = tagValues(plan.Tags)
 
 	var networkInterfaceKeys []string
 	for k := range plan.NetworkInterfaces {
@@ -310,10 +590,11 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	for _, k := range keys {
 		plannedNetworkInterface := plan.NetworkInterfaces[k]
 		createNetworkInterfaces = append(createNetworkInterfaces, client.NetworkInterface{
-			Network:   plannedNetworkInterface.Network.ValueString(),
-			Connected: plannedNetworkInterface.Connected.ValueBool(),
-			Label:     k,
-			Type:      plannedNetworkInterface.Type.ValueString(),
+			Network:           plannedNetworkInterface.Network.ValueString(),
+			Connected:         plannedNetworkInterface.Connected.ValueBool(),
+			Label:             k,
+			Type:              plannedNetworkInterface.Type.ValueString(),
+			AssignedAddresses: configuredAddresses(plannedNetworkInterface),
 		})
 	}
 	create.NetworkInterfaces = createNetworkInterfaces
@@ -322,17 +603,8 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 	var createDisks []client.Disk
 	for _, k := range keys {
 		plannedDisk := plan.Disks[k]
-		var newDiskId string
-		if len(existingDisks) > len(createDisks) {
-			existingDisk := existingDisks[len(createDisks)]
-			if plannedDisk.Size.ValueInt64() < int64(existingDisk.Size) {
-				resp.Diagnostics.AddError("Disks cannot be smaller when cloning", fmt.Sprintf("Disk %s is smaller than source virtual server disk", k))
-				return
-			}
-			newDiskId = existingDisk.Id
-		}
 		createDisks = append(createDisks, client.Disk{
-			Id:    newDiskId,
+			Id:    sourceDisks[k].Id,
 			Size:  uint64(plannedDisk.Size.ValueInt64()),
 			Label: k,
 		})
@@ -340,53 +612,87 @@ func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, r
 
 	create.Disks = createDisks
 
-	task, err := r.client.VirtualServer.Create(&create)
+	task, err := util.CreateVirtualMachine(r.client, &create)
 	if err != nil {
 		resp.Diagnostics.AddError("Error while creating Virtual Server", fmt.Sprintf("Error while creating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
 		return
 	}
 
-	_, _ = r.client.Task.WaitFor(task.Id, 5*time.Minute)
+	// The virtual server exists from now on, an interrupted create is checked by Read
+	resp.Diagnostics.Append(util.SetPendingCreate(ctx, resp.Private, util.PendingCreate{Id: task.VirtualMachine, TaskId: task.Id})...)
 
-	vm, err := r.client.VirtualServer.Get(task.VirtualMachine)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual server create task", map[string]any{util.LogFieldId: task.VirtualMachine, util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 5*time.Minute, util.VirtualMachineState(r.client, task.VirtualMachine))
 	if err != nil {
-		resp.Diagnostics.AddError("Virtual server could not be found after creation", fmt.Sprintf("Error while creating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
+		r.setPendingCreateState(ctx, resp, &plan, task.VirtualMachine, err)
 		return
 	}
 
-	populateResourceData(ctx, &data, vm, &plan)
-
-	data.Id = types.StringValue(task.VirtualMachine)
-	if plan.Source.IsNull() || plan.Source.ValueString() == "" {
-		data.Source = types.StringValue("")
-	} else {
-		data.Source = plan.Source
-	}
-
-	data.UserData = plan.UserData
-
-	if len(plan.Template.ValueString()) == 0 {
+	if !create.PowerOnAfterClone {
+		// The virtual server is deployed when it is either on or off, the power state is applied below
+		err = waitForVirtualServerState(ctx, r.client, task.VirtualMachine, client.VmStatePoweredOn, client.VmStatePoweredOff)
+	} else if len(plan.Template.ValueString()) == 0 {
 		if len(plan.GuestId.ValueString()) == 0 {
 			// Clone
-			err = waitForVirtualServerState(r.client, data.Id.ValueString(), client.VmStatePoweredOff)
+			err = waitForVirtualServerState(ctx, r.client, task.VirtualMachine, client.VmStatePoweredOff)
 		} else {
 			// Set guest ID
-			err = waitForVirtualServerState(r.client, data.Id.ValueString(), client.VmStatePoweredOn)
+			err = waitForVirtualServerState(ctx, r.client, task.VirtualMachine, client.VmStatePoweredOn)
 		}
 	} else {
 		// Template should always power on
-		err = waitForVirtualServerState(r.client, data.Id.ValueString(), client.VmStatePoweredOn)
+		err = waitForVirtualServerState(ctx, r.client, task.VirtualMachine, client.VmStatePoweredOn)
+	}
+
+	if err != nil {
+		r.setPendingCreateState(ctx, resp, &plan, task.VirtualMachine, err)
+		return
+	}
+	resp.Diagnostics.Append(util.ClearPendingCreate(ctx, resp.Private)...)
+
+	if !plan.PowerState.IsUnknown() && !plan.PowerState.IsNull() {
+		err = applyPowerState(ctx, r.client, &resp.Diagnostics, task.VirtualMachine, plan.PowerState.ValueString())
+		if err != nil {
+			return
+		}
+	}
+
+	guestIp, _, newDiags := parseWaitForGuestIp(plan.WaitForGuestIp)
+	resp.Diagnostics.Append(newDiags...)
+	if guestIp.Enabled {
+		if create.PowerOnAfterClone {
+			err = waitForGuestIp(ctx, r.client, task.VirtualMachine, guestIp.Labels, guestIp.Timeout)
+			if err != nil {
+				resp.Diagnostics.AddWarning("No guest IP address", fmt.Sprintf("Virtual Server (%s) has no usable IP address yet, the addresses are read on the next refresh: %s", task.VirtualMachine, err))
+			}
+		} else {
+			resp.Diagnostics.AddWarning("No guest IP address", fmt.Sprintf("Virtual Server (%s) is not powered on, not waiting for a guest IP address", task.VirtualMachine))
+		}
 	}
 
+	vm, err := util.GetVirtualMachine(r.client, task.VirtualMachine)
 	if err != nil {
-		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error waiting for Virtual Server (%s) to become ready: %s", data.Id, err))
+		resp.Diagnostics.AddError("Virtual server could not be found after creation", fmt.Sprintf("Error while creating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
 		return
 	}
 
+	resp.Diagnostics.Append(checkCpuSockets(plan, vm)...)
+	populateResourceData(ctx, &data, vm, &plan)
+	setAppliedUserData(&data, plan)
+
+	data.Id = types.StringValue(task.VirtualMachine)
+	if plan.Source.IsNull() || plan.Source.ValueString() == "" {
+		data.Source = types.StringValue("")
+	} else {
+		data.Source = plan.Source
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 }
 
 func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 
 	var state, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -394,7 +700,7 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 	// Retrieve the VirtualMachine properties for updating the state
-	vm, err := r.client.VirtualServer.Get(state.Id.ValueString())
+	vm, err := util.GetVirtualMachine(r.client, state.Id.ValueString())
 
 	if err != nil {
 		if err.(*client.ApiError).Code == 404 {
@@ -405,57 +711,105 @@ func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	pending, diags := util.GetPendingCreate(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if pending != nil {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Checking interrupted virtual server create", map[string]any{util.LogFieldId: pending.Id, util.LogFieldTaskId: pending.TaskId, util.LogFieldState: vm.State})
+		resp.Diagnostics.Append(r.checkPendingCreate(ctx, resp, pending, vm)...)
+	}
+
 	populateResourceData(ctx, &data, vm, &state)
-	data.UserData = state.UserData
+	keepUserData(&data, state)
 	data.Source = state.Source
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 
 }
 
 func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var state, plan, data resourceData
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, plan, config, data resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateDiskChanges(state, plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	unlock := util.LockVirtualMachine(state.Id.ValueString())
+	defer unlock()
+
 	var machineHasShutdown = false
-	var vm *client.VirtualMachineExt
-	update := client.VirtualMachineUpdate{}
+	var vm *util.VirtualMachineExt
+	update := util.VirtualMachineUpdate{}
 
-	vm, err := r.client.VirtualServer.Get(state.Id.ValueString())
+	vm, err := util.GetVirtualMachine(r.client, state.Id.ValueString())
 
 	if err != nil {
 		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while getting Virtual Server: %s", state.Id))
 		return
 	}
 
-	update.Name = plan.Name.ValueString()
-	update.ComputeCluster = plan.ComputeCluster.ValueString()
-	update.Group = plan.Group.ValueString()
-
-	if plan.CpuCores.ValueInt64() != state.CpuCores.ValueInt64() ||
-		plan.Memory.ValueInt64() != state.Memory.ValueInt64() {
-		resp.Diagnostics.AddWarning("Virtual server shutdown", fmt.Sprintf("Virtual server shutdown to alter cpu cores or memory quantity %s", state.Id))
+	// The migration runs before all other changes, a failed migration leaves the virtual server as it was
+	if !plan.ComputeCluster.Equal(state.ComputeCluster) {
+		timeout := defaultMigrationTimeout
+		if !plan.MigrationTimeout.IsNull() {
+			timeout, _ = time.ParseDuration(plan.MigrationTimeout.ValueString())
+		}
 
-		err := gracefullyShutdownVirtualMachine(r.client, &resp.Diagnostics, vm.Id)
+		err = migrateVirtualMachine(ctx, r.client, vm, plan.ComputeCluster.ValueString(), timeout)
+		if err != nil {
+			resp.Diagnostics.AddError("Virtual server not migrated", fmt.Sprintf("Virtual server %s could not be migrated to compute cluster %s, no other changes are made: %s", state.Name.ValueString(), plan.ComputeCluster.ValueString(), err))
+			r.setFailedUpdateState(ctx, resp, state)
+			return
+		}
 
+		vm, err = util.GetVirtualMachine(r.client, state.Id.ValueString())
 		if err != nil {
+			resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while getting Virtual Server after migration: %s", state.Id))
 			return
 		}
-		machineHasShutdown = true
+	}
 
+	update.Name = plan.Name.ValueString()
+	update.ComputeCluster = plan.ComputeCluster.ValueString()
+	update.Group = plan.Group.ValueString()
+	// The update sends every field, a template made with previder_virtual_server_template stays a template
+	update.MarkedAsTemplate = vm.MarkedAsTemplate
+
+	var tryHotAdd = false
+	if resizeNeeded(state, plan) {
+		resizePolicy := plan.ResizePolicy.ValueString()
+		switch {
+		case vm.State == client.VmStatePoweredOff:
+			// No shutdown needed
+		case resizePolicy == resizePolicyDeny:
+			resp.Diagnostics.AddError("Virtual server resize denied", fmt.Sprintf("Virtual server %s has to be shut down to alter cpu cores, cpu sockets or memory quantity, but resize_policy is %s", state.Id, resizePolicyDeny))
+			return
+		case resizePolicy == resizePolicyHotAdd && canHotAdd(state, plan):
+			tryHotAdd = true
+		default:
+			resp.Diagnostics.AddWarning("Virtual server shutdown", fmt.Sprintf("Virtual server shutdown to alter cpu cores, cpu sockets or memory quantity %s", state.Id))
+
+			err := gracefullyShutdownVirtualMachine(ctx, r.client, &resp.Diagnostics, vm.Id, resizePolicy != resizePolicyGracefulOnly)
+			if err != nil {
+				r.setFailedUpdateState(ctx, resp, state)
+				return
+			}
+			machineHasShutdown = true
+		}
 	}
 	update.CpuCores = int(plan.CpuCores.ValueInt64())
+	update.CpuSockets = int(plan.CpuSockets.ValueInt64())
 	update.Memory = uint64(plan.Memory.ValueInt64())
 
-	updateTags := make([]string, len(plan.Tags))
-	for i, tag := range plan.Tags {
-		updateTags[i] = tag.ValueString()
-	}
-	update.Tags = updateTags
+	update.Tags = tagValues(plan.Tags)
 
 	var updateDisks []client.DiskUpdate
 
@@ -497,7 +851,7 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	update.Disks = updateDisks
 
-	var updateNetworkInterfaces []client.NetworkInterfaceUpdate
+	var updateNetworkInterfaces []util.NetworkInterfaceUpdate
 
 	keys = sorters.SortMapKeys(plan.NetworkInterfaces)
 
@@ -507,11 +861,14 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		if existingNetworkInterface, ok := state.NetworkInterfaces[k]; ok {
 			networkInterfaceId = existingNetworkInterface.Id.ValueString()
 		}
-		updateNetworkInterfaces = append(updateNetworkInterfaces, client.NetworkInterfaceUpdate{
-			Id:        networkInterfaceId,
-			Network:   plannedNetworkInterface.Network.ValueString(),
-			Label:     k,
-			Connected: plannedNetworkInterface.Connected.ValueBool(),
+		updateNetworkInterfaces = append(updateNetworkInterfaces, util.NetworkInterfaceUpdate{
+			NetworkInterfaceUpdate: client.NetworkInterfaceUpdate{
+				Id:        networkInterfaceId,
+				Network:   plannedNetworkInterface.Network.ValueString(),
+				Label:     k,
+				Connected: plannedNetworkInterface.Connected.ValueBool(),
+			},
+			AssignedAddresses: updatedAddresses(config.NetworkInterfaces[k], state.NetworkInterfaces[k]),
 		})
 		resp.Diagnostics.AddWarning("updating network interface for virtual server", fmt.Sprintf("Updating network interface with label %s", plannedNetworkInterface.Label.ValueString()))
 	}
@@ -526,11 +883,13 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 		if !found {
 			resp.Diagnostics.AddWarning("removing network interface from virtual server", fmt.Sprintf("Removing network interface with label %s", existingNetworkInterface.Label.ValueString()))
 
-			updateNetworkInterfaces = append(updateNetworkInterfaces, client.NetworkInterfaceUpdate{
-				Id:      existingNetworkInterface.Id.ValueString(),
-				Network: existingNetworkInterface.Network.ValueString(),
-				Label:   existingNetworkInterface.Label.ValueString(),
-				Deleted: true,
+			updateNetworkInterfaces = append(updateNetworkInterfaces, util.NetworkInterfaceUpdate{
+				NetworkInterfaceUpdate: client.NetworkInterfaceUpdate{
+					Id:      existingNetworkInterface.Id.ValueString(),
+					Network: existingNetworkInterface.Network.ValueString(),
+					Label:   existingNetworkInterface.Label.ValueString(),
+					Deleted: true,
+				},
 			})
 		}
 	}
@@ -539,50 +898,79 @@ func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	update.TerminationProtectionEnabled = plan.TerminationProtection.ValueBool()
 
-	task, err := r.client.VirtualServer.Update(state.Id.ValueString(), &update)
+	task, err := util.UpdateVirtualMachine(r.client, state.Id.ValueString(), &update)
 
 	if err != nil {
 		resp.Diagnostics.AddError("Error updating virtual server", fmt.Sprintf("Virtual server has not been updated %s: %s", state.Name, err.Error()))
+		r.setFailedUpdateState(ctx, resp, state)
 		return
 	}
 
-	virtualMachineTask, _ := r.client.Task.WaitFor(task.Id, 5*time.Minute)
-	if !virtualMachineTask.Success {
-		resp.Diagnostics.AddError("Virtual server could not be updated", fmt.Sprintf("Error while updating VirtualMachine (%s): %s", plan.Name.ValueString(), virtualMachineTask.ErrorMessage))
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual server update task", map[string]any{util.LogFieldId: state.Id.ValueString(), util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 5*time.Minute, util.VirtualMachineState(r.client, state.Id.ValueString()))
+	if tryHotAdd && err != nil {
+		resp.Diagnostics.AddWarning("Virtual server shutdown", fmt.Sprintf("Virtual server %s could not be resized while running (%s), shutting down to alter cpu cores or memory quantity", state.Id, err))
+
+		err = gracefullyShutdownVirtualMachine(ctx, r.client, &resp.Diagnostics, vm.Id, true)
+		if err != nil {
+			r.setFailedUpdateState(ctx, resp, state)
+			return
+		}
+		machineHasShutdown = true
+
+		task, err = util.UpdateVirtualMachine(r.client, state.Id.ValueString(), &update)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating virtual server", fmt.Sprintf("Virtual server has not been updated %s: %s", state.Name, err.Error()))
+			r.setFailedUpdateState(ctx, resp, state)
+			return
+		}
+		tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual server update task", map[string]any{util.LogFieldId: state.Id.ValueString(), util.LogFieldTaskId: task.Id})
+		_, err = util.CheckTask(ctx, r.client, task.Id, 5*time.Minute, util.VirtualMachineState(r.client, state.Id.ValueString()))
 	}
-	if machineHasShutdown == true {
-		task, err = r.client.VirtualServer.Control(state.Id.ValueString(), client.VmActionPowerOn)
-		virtualMachineTask, err = r.client.Task.WaitFor(task.Id, 5*time.Minute)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server could not be updated", fmt.Sprintf("Error while updating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
+		r.setFailedUpdateState(ctx, resp, state)
+		return
+	}
+	desiredPowerState := plan.PowerState.ValueString()
+	if machineHasShutdown == true && desiredPowerState != powerStateOff && desiredPowerState != powerStateSuspended {
+		tflog.SubsystemInfo(ctx, logSubsystem, "Powering on virtual server", map[string]any{util.LogFieldId: state.Id.ValueString()})
+		err = controlVirtualMachine(ctx, r.client, state.Id.ValueString(), client.VmActionPowerOn, client.VmStatePoweredOn)
 		if err != nil {
+			resp.Diagnostics.AddError("Virtual server not powered on", fmt.Sprintf("Virtual Server %s is not powered on after altering cpu cores, cpu sockets or memory quantity: %s", state.Id, err))
 			return
 		}
-		resp.Diagnostics.AddWarning("Virtual server powered on", fmt.Sprintf("Virtual server poweredon after altering cpu cores or memory quantity %s", state.Id))
+		resp.Diagnostics.AddWarning("Virtual server powered on", fmt.Sprintf("Virtual server poweredon after altering cpu cores, cpu sockets or memory quantity %s", state.Id))
+	}
 
-		err = waitForVirtualServerState(r.client, state.Id.ValueString(), client.VmStatePoweredOn)
+	if !plan.PowerState.IsUnknown() && desiredPowerState != "" {
+		err = applyPowerState(ctx, r.client, &resp.Diagnostics, state.Id.ValueString(), desiredPowerState)
 		if err != nil {
-			resp.Diagnostics.AddError("Error while waiting for poweredon", fmt.Sprintf("Virtual Server is not poweredon: %s", err.Error()))
+			return
 		}
 	}
 
-	vm, err = r.client.VirtualServer.Get(state.Id.ValueString())
+	vm, err = util.GetVirtualMachine(r.client, state.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Virtual server could not be found after update", fmt.Sprintf("Error while updating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
 		return
 	}
 
+	resp.Diagnostics.Append(checkCpuSockets(plan, vm)...)
 	populateResourceData(ctx, &data, vm, &plan)
+	setAppliedUserData(&data, plan)
 	if plan.Source.IsNull() || plan.Source.ValueString() == "" {
 		data.Source = types.StringValue("")
 	} else {
 		data.Source = plan.Source
 	}
-	// Always set userdata, should not recreate a server based on this data
-	data.UserData = plan.UserData
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
 
 }
 
 func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var state resourceData
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -609,7 +997,8 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	_, err = r.client.Task.WaitFor(task.Id, 30*time.Minute)
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual server delete task", map[string]any{util.LogFieldId: state.Id.ValueString(), util.LogFieldTaskId: task.Id})
+	_, err = util.CheckTask(ctx, r.client, task.Id, 30*time.Minute, util.VirtualMachineState(r.client, state.Id.ValueString()))
 	if err != nil {
 		resp.Diagnostics.AddError("Virtual server not deleted", fmt.Sprintf("Virtual server is not deleted: %s", err.Error()))
 		return
@@ -620,13 +1009,157 @@ func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, r
 }
 
 func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
 	var data resourceData
 
-	var vm, _ = r.client.VirtualServer.Get(req.ID)
+	importId, newDiags := util.ImportIdFromRequest(ctx, req)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, customerId, newDiags := util.ResolveImportId(importId, r.listImportCandidates)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	importClient, newDiags := util.ImportClient(r.client, r.customer, r.customerClient, customerId)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(importClient, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while importing Virtual Server (%s): %s", id, err))
+		return
+	}
 
 	populateResourceData(ctx, &data, vm, nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
+
+}
+
+func (r *resourceImpl) listImportCandidates(query string) ([]util.ImportCandidate, error) {
+	vms, err := util.PageAll(r.client.VirtualServer.Page, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []util.ImportCandidate
+	for _, vm := range vms {
+		candidates = append(candidates, util.ImportCandidate{Id: vm.Id, Name: vm.Name, Group: vm.Group, GroupName: vm.GroupName})
+	}
+	return candidates, nil
+}
+
+// validateDiskChanges rejects disks that shrink, and disks that are removed without allow_disk_deletion
+func validateDiskChanges(state resourceData, plan resourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, k := range sorters.SortMapKeys(state.Disks) {
+		existingDisk := state.Disks[k]
+		plannedDisk, ok := plan.Disks[k]
+		if !ok {
+			if !plan.AllowDiskDeletion.ValueBool() {
+				diags.AddAttributeError(path.Root("disks").AtMapKey(k), "Disk cannot be deleted", fmt.Sprintf("Disk %s would be removed from virtual server %s and all data on the disk would be lost, set allow_disk_deletion to true to delete the disk", k, state.Name.ValueString()))
+			}
+			continue
+		}
+		if !plannedDisk.Size.IsUnknown() && plannedDisk.Size.ValueInt64() < existingDisk.Size.ValueInt64() {
+			diags.AddAttributeError(path.Root("disks").AtMapKey(k).AtName("size"), "Disks cannot be smaller", fmt.Sprintf("Disk %s of virtual server %s cannot shrink from %d to %d", k, state.Name.ValueString(), existingDisk.Size.ValueInt64(), plannedDisk.Size.ValueInt64()))
+		}
+	}
+
+	return diags
+}
+
+// validateNetworkInterfaceAddresses rejects addresses that are planned on more than one network interface
+func validateNetworkInterfaceAddresses(plan resourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	used := make(map[string]string)
+	for _, k := range sorters.SortMapKeys(plan.NetworkInterfaces) {
+		for _, address := range configuredAddresses(plan.NetworkInterfaces[k]) {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				continue
+			}
+			if other, ok := used[ip.String()]; ok {
+				diags.AddAttributeError(path.Root("network_interfaces").AtMapKey(k), "Duplicate IP address", fmt.Sprintf("Address %s of network interface %s is also planned on network interface %s", address, k, other))
+				continue
+			}
+			used[ip.String()] = k
+		}
+	}
+
+	return diags
+}
+
+// configuredAddresses returns the known static IPv4 and IPv6 address of a network interface
+func configuredAddresses(networkInterface resourceDataNetworkInterface) []string {
+	var addresses []string
+	for _, address := range []types.String{networkInterface.StaticIPv4Address, networkInterface.StaticIPv6Address} {
+		if !address.IsNull() && !address.IsUnknown() && address.ValueString() != "" {
+			addresses = append(addresses, address.ValueString())
+		}
+	}
+	return addresses
+}
+
+// updatedAddresses returns the assigned addresses to send for a network interface. Static addresses removed from the
+// config are unassigned, addresses assigned outside of Terraform are kept.
+func updatedAddresses(config resourceDataNetworkInterface, state resourceDataNetworkInterface) []string {
+	if addresses := configuredAddresses(config); len(addresses) > 0 {
+		return addresses
+	}
+	if len(configuredAddresses(state)) > 0 {
+		return []string{}
+	}
+
+	addresses := []string{}
+	for _, address := range state.AssignedAddresses.Elements() {
+		if value, ok := address.(types.String); ok && !value.IsUnknown() {
+			addresses = append(addresses, value.ValueString())
+		}
+	}
+	return addresses
+}
+
+// addressesChanged returns true when the observed addresses of a network interface change with the plan
+func addressesChanged(state resourceDataNetworkInterface, plan resourceDataNetworkInterface) bool {
+	return !plan.StaticIPv4Address.Equal(state.StaticIPv4Address) ||
+		!plan.StaticIPv6Address.Equal(state.StaticIPv6Address) ||
+		!plan.Network.Equal(state.Network)
+}
+
+// resizeNeeded returns true when the cpu or memory of the virtual server changes
+func resizeNeeded(state resourceData, plan resourceData) bool {
+	return plan.CpuCores.ValueInt64() != state.CpuCores.ValueInt64() ||
+		(!plan.CpuSockets.IsUnknown() && plan.CpuSockets.ValueInt64() != state.CpuSockets.ValueInt64()) ||
+		plan.Memory.ValueInt64() != state.Memory.ValueInt64()
+}
+
+// checkCpuSockets reports an error when the virtual server does not have the planned number of sockets. The API
+// ignores fields it does not know, so the sockets are compared after the change instead of storing a different value.
+func checkCpuSockets(plan resourceData, vm *util.VirtualMachineExt) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if plan.CpuSockets.IsUnknown() || plan.CpuSockets.IsNull() {
+		return diags
+	}
+	if int64(vm.Sockets()) != plan.CpuSockets.ValueInt64() {
+		diags.AddAttributeError(path.Root("cpu_sockets"), "CPU sockets not applied", fmt.Sprintf("Virtual Server %s has %d CPU sockets instead of %d, the Previder API did not apply cpu_sockets", vm.Id, vm.Sockets(), plan.CpuSockets.ValueInt64()))
+	}
+	return diags
+}
 
+// canHotAdd returns true when cpu cores and memory only grow, sockets and shrinking always need a shutdown
+func canHotAdd(state resourceData, plan resourceData) bool {
+	return plan.CpuCores.ValueInt64() >= state.CpuCores.ValueInt64() &&
+		plan.CpuSockets.ValueInt64() == state.CpuSockets.ValueInt64() &&
+		plan.Memory.ValueInt64() >= state.Memory.ValueInt64()
 }
 
 func validVirtualServerSource(data resourceData) bool {
@@ -637,27 +1170,116 @@ func validVirtualServerSource(data resourceData) bool {
 	if !data.GuestId.IsNull() && data.GuestId.ValueString() != "" {
 		count++
 	}
-	if !data.GuestId.IsNull() && data.Source.ValueString() != "" {
+	if !data.Source.IsNull() && data.Source.ValueString() != "" {
 		count++
 	}
 	return count == 1
 }
 
-func waitForVirtualServerState(client *client.PreviderClient, id string, target string) error {
+// validateSourceDisks checks at plan time that the source virtual server exists and that its disks are mapped
+func (r *resourceImpl) validateSourceDisks(plan resourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	sourceVm, err := r.client.VirtualServer.Get(plan.Source.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("source"), "Source virtual server not found", fmt.Sprintf("Error while getting source Virtual Server (%s): %s", plan.Source.ValueString(), err))
+		return diags
+	}
+
+	_, newDiags := mapSourceDisks(sourceVm.Disks, plan)
+	diags.Append(newDiags...)
+	if !explicitSourceDisks(plan) && len(sourceVm.Disks) > 0 {
+		diags.AddAttributeWarning(path.Root("disks"), "Source disks mapped by position", fmt.Sprintf("The disks of source virtual server %s are cloned in the order of the sorted disk labels, renaming a disk changes which source disk is cloned. Set source_disk on the disks to map them explicitly", sourceVm.Name))
+	}
+	return diags
+}
+
+// setPendingCreateState stores a virtual server that was created but did not become ready, so the next apply does not
+// create a duplicate. An interrupted create is checked by Read, other failures leave a tainted virtual server.
+// setFailedUpdateState stores the virtual server as it is after a failed update. The planned values are not applied,
+// the attributes the API does not return are kept from the state. The prior state is kept when the virtual server
+// cannot be read, the response state is the plan otherwise.
+func (r *resourceImpl) setFailedUpdateState(ctx context.Context, resp *resource.UpdateResponse, state resourceData) {
+	vm, err := util.GetVirtualMachine(r.client, state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+		return
+	}
+
+	var data resourceData
+	populateResourceData(ctx, &data, vm, &state)
+	keepUserData(&data, state)
+	data.Source = state.Source
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// checkPendingCreate clears the pending create when its task has completed and the virtual server is powered on or
+// off, otherwise the next refresh checks it again
+func (r *resourceImpl) checkPendingCreate(ctx context.Context, resp *resource.ReadResponse, pending *util.PendingCreate, vm *util.VirtualMachineExt) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if pending.TaskId != "" {
+		task, err := r.client.Task.Get(pending.TaskId)
+		switch {
+		case err != nil:
+			diags.AddWarning("Virtual server not ready", fmt.Sprintf("Create task %s of Virtual Server (%s) could not be fetched, it is checked again on the next refresh: %s", pending.TaskId, pending.Id, err))
+			return diags
+		case task.Completed && !task.Success:
+			diags.AddWarning("Virtual server not ready", fmt.Sprintf("Create task %s of Virtual Server (%s) failed, it is checked again on the next refresh: %s", pending.TaskId, pending.Id, task.ErrorMessage))
+			return diags
+		case !task.Completed:
+			diags.AddWarning("Virtual server not ready", fmt.Sprintf("Virtual Server (%s) is still being created (%d%%), it is checked again on the next refresh", pending.Id, task.Progress))
+			return diags
+		}
+	}
+
+	if vm.State != client.VmStatePoweredOn && vm.State != client.VmStatePoweredOff {
+		diags.AddWarning("Virtual server not ready", fmt.Sprintf("Virtual Server (%s) is still being created (%s), it is checked again on the next refresh", pending.Id, vm.State))
+		return diags
+	}
+
+	diags.Append(util.ClearPendingCreate(ctx, resp.Private)...)
+	return diags
+}
+
+func (r *resourceImpl) setPendingCreateState(ctx context.Context, resp *resource.CreateResponse, plan *resourceData, id string, err error) {
+	var data resourceData
+
+	resp.Diagnostics.Append(util.PendingCreateDiagnostics(ctx, "Virtual Server", id, err)...)
+
+	vm, err := util.GetVirtualMachine(r.client, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server could not be found after creation", fmt.Sprintf("Error while creating VirtualMachine (%s): %s", plan.Name.ValueString(), err))
+		return
+	}
+
+	populateResourceData(ctx, &data, vm, plan)
+	setAppliedUserData(&data, *plan)
+	if plan.Source.IsNull() || plan.Source.ValueString() == "" {
+		data.Source = types.StringValue("")
+	} else {
+		data.Source = plan.Source
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, util.ResourceIdentity{Id: data.Id})...)
+}
+
+func waitForVirtualServerState(ctx context.Context, client *client.PreviderClient, id string, targets ...string) error {
 
 	backoffOperation := func() error {
 		vm, err := client.VirtualServer.Get(id)
-		log.Printf("Waiting for virtual server to become ready. Current state: %v, Expected state: %v", vm.State, target)
 		if err != nil {
+			tflog.SubsystemDebug(ctx, logSubsystem, "Virtual server could not be fetched while waiting for state", map[string]any{util.LogFieldId: id, util.LogFieldError: err.Error()})
 			return errors.New(fmt.Sprintf("invalid Virtual Server id: %s", id))
 		}
-		if vm.State != target {
+		tflog.SubsystemDebug(ctx, logSubsystem, "Waiting for virtual server to become ready", map[string]any{util.LogFieldId: id, util.LogFieldState: vm.State, util.LogFieldTarget: strings.Join(targets, ",")})
+		if !slices.Contains(targets, vm.State) {
 			return errors.New(fmt.Sprintf("Waiting for Virtual Server to become ready: %s", id))
 		}
 		return nil
 	}
 	// Max waiting time is 10 mins
-	backoffConfig := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*5), 120)
+	backoffConfig := backoff.WithContext(backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Second*5), 120), ctx)
 
 	err := backoff.Retry(backoffOperation, backoffConfig)
 	if err != nil {
@@ -667,30 +1289,50 @@ func waitForVirtualServerState(client *client.PreviderClient, id string, target
 	return nil
 }
 
-func gracefullyShutdownVirtualMachine(baseClient *client.PreviderClient, diag *diag.Diagnostics, id string) error {
+// gracefullyShutdownVirtualMachine shuts down the guest, when that fails and force is set the virtual server is powered off
+func gracefullyShutdownVirtualMachine(ctx context.Context, baseClient *client.PreviderClient, diag *diag.Diagnostics, id string, force bool) error {
 
 	vm, err := baseClient.VirtualServer.Get(id)
+	if err != nil {
+		diag.AddError("Virtual server not found", fmt.Sprintf("Error while getting Virtual Server %s: %s", id, err.Error()))
+		return err
+	}
 	if vm.State == client.VmStatePoweredOff {
 		return nil
 	}
 
-	task, err := baseClient.VirtualServer.Control(vm.Id, client.VmActionShutdown)
-	if err != nil {
-		diag.AddError("Virtual server not shutting down", fmt.Sprintf("Virtual Server is not shutting down after shutdown command: %s", err.Error()))
-		task, err = baseClient.VirtualServer.Control(vm.Id, client.VmActionPowerOff)
+	err = controlVirtualMachine(ctx, baseClient, id, client.VmActionShutdown, client.VmStatePoweredOff)
+	if err == nil {
+		return nil
+	}
+	if !force {
+		diag.AddError("Virtual server not shutting down", fmt.Sprintf("Virtual Server is not shutting down after shutdown command and resize_policy is %s: %s", resizePolicyGracefulOnly, err.Error()))
+		return err
 	}
 
-	_, err = baseClient.Task.WaitFor(task.Id, 5*time.Minute)
+	diag.AddWarning("Virtual server not shutting down", fmt.Sprintf("Virtual Server is not shutting down after shutdown command, powering off: %s", err.Error()))
+	err = controlVirtualMachine(ctx, baseClient, id, client.VmActionPowerOff, client.VmStatePoweredOff)
 	if err != nil {
 		diag.AddError("Virtual server power off failed", fmt.Sprintf("Virtual Server is not powering off after poweroff command: %s", err.Error()))
 		return err
 	}
 
-	err = waitForVirtualServerState(baseClient, id, client.VmStatePoweredOff)
+	return nil
+
+}
+
+// controlVirtualMachine sends a power action and waits until the virtual server has the target state
+func controlVirtualMachine(ctx context.Context, baseClient *client.PreviderClient, id string, action string, target string) error {
+	task, err := baseClient.VirtualServer.Control(id, action)
 	if err != nil {
-		diag.AddError("Error while waiting for shutdown", fmt.Sprintf("Virtual Server is not shutting down after shutdown command: %s", err.Error()))
+		return err
 	}
 
-	return nil
+	tflog.SubsystemInfo(ctx, logSubsystem, "Waiting for virtual server power task", map[string]any{util.LogFieldId: id, util.LogFieldTaskId: task.Id, "action": action})
+	_, err = util.CheckTask(ctx, baseClient, task.Id, 5*time.Minute, util.VirtualMachineState(baseClient, id))
+	if err != nil {
+		return err
+	}
 
+	return waitForVirtualServerState(ctx, baseClient, id, target)
 }
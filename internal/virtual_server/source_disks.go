@@ -0,0 +1,113 @@
+package virtual_server
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util/sorters"
+)
+
+// findSourceDisk returns the disk of the source virtual server with the label or uuid
+func findSourceDisk(disks []client.Disk, labelOrUuid string) *client.Disk {
+	for i, disk := range disks {
+		if disk.Label == labelOrUuid || disk.Uuid == labelOrUuid {
+			return &disks[i]
+		}
+	}
+	return nil
+}
+
+// explicitSourceDisks reports whether the disks of the source virtual server are mapped with source_disk and
+// drop_source_disks, otherwise they are mapped by the position of the sorted disk labels
+func explicitSourceDisks(plan resourceData) bool {
+	if len(plan.DropSourceDisks) > 0 {
+		return true
+	}
+	for _, disk := range plan.Disks {
+		if !disk.SourceDisk.IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+// mapSourceDisks returns the disk of the source virtual server that is cloned for each planned disk label. With
+// explicit mapping every source disk has to be mapped by one planned disk or be dropped.
+func mapSourceDisks(sourceDisks []client.Disk, plan resourceData) (map[string]client.Disk, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	mapping := make(map[string]client.Disk)
+	keys := sorters.SortMapKeys(plan.Disks)
+
+	if !explicitSourceDisks(plan) {
+		for i, k := range keys {
+			if i < len(sourceDisks) {
+				diags.Append(checkSourceDiskSize(k, plan.Disks[k], sourceDisks[i])...)
+				mapping[k] = sourceDisks[i]
+			}
+		}
+		return mapping, diags
+	}
+
+	// Unknown values are checked again during apply
+	complete := true
+	mapped := make(map[string]string)
+	for _, k := range keys {
+		sourceDiskName := plan.Disks[k].SourceDisk
+		if sourceDiskName.IsUnknown() {
+			complete = false
+			continue
+		}
+		if sourceDiskName.IsNull() {
+			continue
+		}
+		sourceDisk := findSourceDisk(sourceDisks, sourceDiskName.ValueString())
+		if sourceDisk == nil {
+			diags.AddAttributeError(path.Root("disks").AtMapKey(k).AtName("source_disk"), "Source disk not found", fmt.Sprintf("Source virtual server has no disk with label or uuid %s", sourceDiskName.ValueString()))
+			continue
+		}
+		if other, ok := mapped[sourceDisk.Id]; ok {
+			diags.AddAttributeError(path.Root("disks").AtMapKey(k).AtName("source_disk"), "Source disk mapped twice", fmt.Sprintf("Source disk %s is already mapped to disk %s", sourceDisk.Label, other))
+			continue
+		}
+		diags.Append(checkSourceDiskSize(k, plan.Disks[k], *sourceDisk)...)
+		mapped[sourceDisk.Id] = k
+		mapping[k] = *sourceDisk
+	}
+
+	dropped := make(map[string]bool)
+	for _, drop := range plan.DropSourceDisks {
+		if drop.IsUnknown() {
+			complete = false
+			continue
+		}
+		sourceDisk := findSourceDisk(sourceDisks, drop.ValueString())
+		if sourceDisk == nil {
+			diags.AddAttributeError(path.Root("drop_source_disks"), "Source disk not found", fmt.Sprintf("Source virtual server has no disk with label or uuid %s", drop.ValueString()))
+			continue
+		}
+		if other, ok := mapped[sourceDisk.Id]; ok {
+			diags.AddAttributeError(path.Root("drop_source_disks"), "Source disk mapped and dropped", fmt.Sprintf("Source disk %s is mapped to disk %s and cannot be dropped", sourceDisk.Label, other))
+		}
+		dropped[sourceDisk.Id] = true
+	}
+
+	for _, sourceDisk := range sourceDisks {
+		if !complete {
+			break
+		}
+		if _, ok := mapped[sourceDisk.Id]; !ok && !dropped[sourceDisk.Id] {
+			diags.AddAttributeError(path.Root("disks"), "Source disk not mapped", fmt.Sprintf("Source disk %s is not the source_disk of a disk and not in drop_source_disks", sourceDisk.Label))
+		}
+	}
+
+	return mapping, diags
+}
+
+func checkSourceDiskSize(label string, disk resourceDataDisk, sourceDisk client.Disk) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !disk.Size.IsUnknown() && disk.Size.ValueInt64() < int64(sourceDisk.Size) {
+		diags.AddAttributeError(path.Root("disks").AtMapKey(label).AtName("size"), "Disks cannot be smaller when cloning", fmt.Sprintf("Disk %s is smaller than source disk %s", label, sourceDisk.Label))
+	}
+	return diags
+}
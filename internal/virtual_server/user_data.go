@@ -0,0 +1,207 @@
+package virtual_server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+)
+
+// maxUserDataSize is the maximum size of the decoded user data
+const maxUserDataSize = 64 * 1024
+
+// userDataSource returns the user data attribute that is set, with the path for diagnostics
+func userDataSource(data resourceData) (types.String, path.Path) {
+	switch {
+	case !data.UserDataWo.IsNull():
+		return data.UserDataWo, path.Root("user_data_wo")
+	case !data.UserDataBase64.IsNull():
+		return data.UserDataBase64, path.Root("user_data_base64")
+	case !data.UserDataGzip.IsNull():
+		return data.UserDataGzip, path.Root("user_data_gzip")
+	}
+	return data.UserData, path.Root("user_data")
+}
+
+// decodeUserData returns the plain user data of user_data, user_data_base64, user_data_gzip or user_data_wo
+func decodeUserData(data resourceData) (string, error) {
+	switch {
+	case !data.UserDataWo.IsNull():
+		return data.UserDataWo.ValueString(), nil
+	case !data.UserDataBase64.IsNull():
+		decoded, err := base64.StdEncoding.DecodeString(data.UserDataBase64.ValueString())
+		if err != nil {
+			return "", fmt.Errorf("user_data_base64 is not valid base64: %w", err)
+		}
+		return string(decoded), nil
+	case !data.UserDataGzip.IsNull():
+		decoded, err := base64.StdEncoding.DecodeString(data.UserDataGzip.ValueString())
+		if err != nil {
+			return "", fmt.Errorf("user_data_gzip is not valid base64: %w", err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return "", fmt.Errorf("user_data_gzip is not gzip compressed: %w", err)
+		}
+		defer reader.Close()
+		// Read one byte more than allowed, so too large user data is detected without reading all of it
+		plain, err := io.ReadAll(io.LimitReader(reader, maxUserDataSize+1))
+		if err != nil {
+			return "", fmt.Errorf("user_data_gzip could not be decompressed: %w", err)
+		}
+		return string(plain), nil
+	}
+	return data.UserData.ValueString(), nil
+}
+
+// validateUserData checks the size, and the syntax of cloud-config and multipart MIME user data. Other formats, like
+// shell scripts, are passed as is.
+func validateUserData(content string) error {
+	if len(content) > maxUserDataSize {
+		return fmt.Errorf("user data is larger than %d bytes", maxUserDataSize)
+	}
+
+	switch {
+	case strings.HasPrefix(content, "#cloud-config"):
+		var parsed map[string]any
+		if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+			return fmt.Errorf("cloud-config is not valid YAML: %w", err)
+		}
+	case strings.HasPrefix(strings.ToLower(content), "content-type: multipart/"), strings.HasPrefix(strings.ToLower(content), "mime-version:"):
+		return validateMultipartUserData(content)
+	}
+
+	return nil
+}
+
+func validateMultipartUserData(content string) error {
+	message, err := mail.ReadMessage(strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("multipart user data has invalid headers: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return errors.New("multipart user data has no multipart Content-Type")
+	}
+	if params["boundary"] == "" {
+		return errors.New("multipart user data has no boundary")
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("multipart user data is invalid: %w", err)
+		}
+
+		var partReader io.Reader = part
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			partReader = base64.NewDecoder(base64.StdEncoding, part)
+		}
+		body, err := io.ReadAll(partReader)
+		if err != nil {
+			return fmt.Errorf("multipart user data is invalid: %w", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if partType == "text/cloud-config" {
+			var parsed map[string]any
+			if err := yaml.Unmarshal(body, &parsed); err != nil {
+				return fmt.Errorf("cloud-config part is not valid YAML: %w", err)
+			}
+		}
+	}
+}
+
+// userDataHash returns the hash of the plain user data, an empty string when there is no user data or when the user
+// data is write-only
+func userDataHash(data resourceData, content string) string {
+	if content == "" || !data.UserDataWo.IsNull() {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ planmodifier.String = userDataHashModifier{}
+
+// userDataHashModifier keeps the user data of the state when the configured user data decodes to content with the
+// hash of the state, like gzip output that differs but contains the same user data. Terraform only allows the state
+// value when the same attribute is set in the state and the config, so switching between user data attributes and
+// the first plan after an import still show a change.
+type userDataHashModifier struct{}
+
+func (m userDataHashModifier) Description(_ context.Context) string {
+	return "Keeps the user data of the state when the decoded user data has the same hash"
+}
+
+func (m userDataHashModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m userDataHashModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.StateValue.IsNull() || req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() || req.StateValue.Equal(req.ConfigValue) {
+		return
+	}
+
+	var stateHash types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("user_data_hash"), &stateHash)...)
+	if resp.Diagnostics.HasError() || stateHash.IsNull() || stateHash.ValueString() == "" {
+		return
+	}
+
+	var configured resourceData
+	switch req.Path.String() {
+	case "user_data_base64":
+		configured.UserDataBase64 = req.ConfigValue
+	case "user_data_gzip":
+		configured.UserDataGzip = req.ConfigValue
+	default:
+		configured.UserData = req.ConfigValue
+	}
+	content, err := decodeUserData(configured)
+	if err != nil {
+		return
+	}
+
+	if userDataHash(configured, content) == stateHash.ValueString() {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// setAppliedUserData stores the user data of an applied plan. The API does not return the user data, the state
+// records the hash of the applied content. The attributes are stored as planned, which is the state value when
+// userDataHashModifier found the same hash.
+func setAppliedUserData(data *resourceData, plan resourceData) {
+	content, _ := decodeUserData(plan)
+	data.UserDataHash = types.StringValue(userDataHash(plan, content))
+	data.UserData = plan.UserData
+	data.UserDataBase64 = plan.UserDataBase64
+	data.UserDataGzip = plan.UserDataGzip
+	data.UserDataWoVersion = plan.UserDataWoVersion
+}
+
+// keepUserData keeps the user data of the state on a read, the API does not return it
+func keepUserData(data *resourceData, state resourceData) {
+	data.UserDataHash = state.UserDataHash
+	data.UserData = state.UserData
+	data.UserDataBase64 = state.UserDataBase64
+	data.UserDataGzip = state.UserDataGzip
+	data.UserDataWoVersion = state.UserDataWoVersion
+}
@@ -0,0 +1,40 @@
+package virtual_server_disk
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+)
+
+type resourceData struct {
+	Id            types.String `tfsdk:"id"`
+	VirtualServer types.String `tfsdk:"virtual_server"`
+	Label         types.String `tfsdk:"label"`
+	Size          types.Int64  `tfsdk:"size"`
+	Uuid          types.String `tfsdk:"uuid"`
+	Index         types.Int64  `tfsdk:"index"`
+}
+
+func populateResourceData(data *resourceData, virtualServerId string, in *client.Disk, index int) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	data.Id = types.StringValue(in.Id)
+	data.VirtualServer = types.StringValue(virtualServerId)
+	data.Label = types.StringValue(in.Label)
+	data.Size = types.Int64Value(int64(in.Size))
+	data.Uuid = types.StringValue(in.Uuid)
+	data.Index = types.Int64Value(int64(index))
+
+	return diags
+}
+
+// findDisk returns the disk with the id, or with the label when no id is known yet, and its index in the disks of the
+// virtual server
+func findDisk(disks []client.Disk, id string, label string) (*client.Disk, int) {
+	for i, disk := range disks {
+		if (id != "" && disk.Id == id) || (id == "" && disk.Label == label) {
+			return &disks[i], i
+		}
+	}
+	return nil, -1
+}
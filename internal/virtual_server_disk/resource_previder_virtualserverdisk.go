@@ -0,0 +1,277 @@
+package virtual_server_disk
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"strings"
+)
+
+const ResourceType = "previder_virtual_server_disk"
+const logSubsystem = "previder.virtual_server_disk"
+
+var stateUpgrades []util.StateUpgrade
+
+var _ resource.Resource = (*resourceImpl)(nil)
+var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
+var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithModifyPlan = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
+
+type resourceImpl struct {
+	client   *client.PreviderClient
+	customer string
+}
+
+func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func NewResource() resource.Resource {
+	return &resourceImpl{}
+}
+
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
+	resp.Schema.Attributes = map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "ID of the disk",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"virtual_server": schema.StringAttribute{
+			MarkdownDescription: "ID of the virtual server the disk is attached to",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"label": schema.StringAttribute{
+			MarkdownDescription: "Label of the disk, must be unique within the virtual server",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"size": schema.Int64Attribute{
+			MarkdownDescription: "Size of the disk, a disk cannot shrink",
+			Required:            true,
+		},
+		"uuid": schema.StringAttribute{
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"index": schema.Int64Attribute{
+			MarkdownDescription: "Index of the disk in the disks of the virtual server. It is not the controller position and changes when other disks are added or removed",
+			Computed:            true,
+		},
+	}
+}
+
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check on create and destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state, plan resourceData
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.Size.IsUnknown() && plan.Size.ValueInt64() < state.Size.ValueInt64() {
+		resp.Diagnostics.AddAttributeError(path.Root("size"), "Disks cannot be smaller", fmt.Sprintf("Disk %s cannot shrink from %d to %d", state.Label.ValueString(), state.Size.ValueInt64(), plan.Size.ValueInt64()))
+	}
+}
+
+func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var plan, data resourceData
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := plan.VirtualServer.ValueString()
+	label := plan.Label.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Attaching disk to virtual server", map[string]any{util.LogFieldId: virtualServerId, "label": label})
+	vm, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for _, disk := range update.Disks {
+			if disk.Label == label {
+				return fmt.Errorf("virtual server already has a disk with label %s", label)
+			}
+		}
+		update.Disks = append(update.Disks, client.DiskUpdate{
+			Size:  uint64(plan.Size.ValueInt64()),
+			Label: label,
+		})
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error while attaching disk", fmt.Sprintf("Error while attaching disk %s to Virtual Server (%s): %s", label, virtualServerId, err))
+		return
+	}
+
+	disk, index := findDisk(vm.Disks, "", label)
+	if disk == nil {
+		resp.Diagnostics.AddError("Disk not found after attaching", fmt.Sprintf("Disk %s is not found on Virtual Server (%s)", label, virtualServerId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, virtualServerId, disk, index)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, data resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, state.VirtualServer.ValueString())
+	if err != nil {
+		if apiError, ok := err.(*client.ApiError); ok && apiError.Code == 404 {
+			tflog.SubsystemWarn(ctx, logSubsystem, "Virtual server of the disk is deleted outside of Terraform", map[string]any{util.LogFieldId: state.VirtualServer.ValueString(), "disk_id": state.Id.ValueString()})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error while fetching Virtual Server (%s): %s", state.VirtualServer.ValueString(), err))
+		return
+	}
+
+	disk, index := findDisk(vm.Disks, state.Id.ValueString(), state.Label.ValueString())
+	if disk == nil {
+		tflog.SubsystemWarn(ctx, logSubsystem, "Disk is detached outside of Terraform", map[string]any{util.LogFieldId: state.VirtualServer.ValueString(), "disk_id": state.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, state.VirtualServer.ValueString(), disk, index)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, plan, data resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := state.VirtualServer.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Resizing disk of virtual server", map[string]any{util.LogFieldId: virtualServerId, "disk_id": state.Id.ValueString()})
+	vm, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for i, disk := range update.Disks {
+			if disk.Id == state.Id.ValueString() {
+				if uint64(plan.Size.ValueInt64()) < disk.Size {
+					return fmt.Errorf("disk %s cannot shrink from %d to %d", disk.Label, disk.Size, plan.Size.ValueInt64())
+				}
+				update.Disks[i].Size = uint64(plan.Size.ValueInt64())
+				return nil
+			}
+		}
+		return fmt.Errorf("disk %s is not attached to the virtual server", state.Id.ValueString())
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error while resizing disk", fmt.Sprintf("Error while resizing disk %s of Virtual Server (%s): %s", state.Label.ValueString(), virtualServerId, err))
+		return
+	}
+
+	disk, index := findDisk(vm.Disks, state.Id.ValueString(), state.Label.ValueString())
+	if disk == nil {
+		resp.Diagnostics.AddError("Disk not found after resizing", fmt.Sprintf("Disk %s is not found on Virtual Server (%s)", state.Label.ValueString(), virtualServerId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, virtualServerId, disk, index)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := state.VirtualServer.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Detaching disk from virtual server", map[string]any{util.LogFieldId: virtualServerId, "disk_id": state.Id.ValueString()})
+	_, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for i, disk := range update.Disks {
+			if disk.Id == state.Id.ValueString() {
+				update.Disks[i].Delete = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Disk not detached", fmt.Sprintf("Error while detaching disk %s from Virtual Server (%s): %s", state.Label.ValueString(), virtualServerId, err))
+	}
+}
+
+func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var data resourceData
+
+	virtualServerId, diskId, found := strings.Cut(req.ID, "/")
+	if !found || virtualServerId == "" || diskId == "" {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Import ID %q must be <virtual server id>/<disk id or label>", req.ID))
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, virtualServerId)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while importing disk of Virtual Server (%s): %s", virtualServerId, err))
+		return
+	}
+
+	disk, index := findDisk(vm.Disks, diskId, "")
+	if disk == nil {
+		disk, index = findDisk(vm.Disks, "", diskId)
+	}
+	if disk == nil {
+		resp.Diagnostics.AddError("Disk not found", fmt.Sprintf("Virtual Server (%s) has no disk with id or label %s", virtualServerId, diskId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, vm.Id, disk, index)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,75 @@
+package virtual_server_network_interface
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"net"
+)
+
+type resourceData struct {
+	Id                  types.String `tfsdk:"id"`
+	VirtualServer       types.String `tfsdk:"virtual_server"`
+	Label               types.String `tfsdk:"label"`
+	Network             types.String `tfsdk:"network"`
+	Connected           types.Bool   `tfsdk:"connected"`
+	Type                types.String `tfsdk:"type"`
+	MACAddress          types.String `tfsdk:"mac_address"`
+	IPv4Address         types.String `tfsdk:"ipv4_address"`
+	IPv6Address         types.String `tfsdk:"ipv6_address"`
+	AssignedAddresses   types.List   `tfsdk:"assigned_addresses"`
+	DiscoveredAddresses types.List   `tfsdk:"discovered_addresses"`
+}
+
+func populateResourceData(ctx context.Context, data *resourceData, virtualServerId string, in *client.NetworkInterface, plan *resourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var newDiags diag.Diagnostics
+
+	if plan == nil {
+		plan = &resourceData{}
+	}
+
+	data.Id = types.StringValue(in.Id)
+	data.VirtualServer = types.StringValue(virtualServerId)
+	data.Label = types.StringValue(in.Label)
+	if util.IsValidObjectId(plan.Network.ValueString()) || plan.Network.IsNull() {
+		data.Network = types.StringValue(in.Network)
+	} else {
+		data.Network = types.StringValue(in.NetworkName)
+	}
+	data.Connected = types.BoolValue(in.Connected)
+	data.Type = types.StringValue(in.Type)
+	data.MACAddress = types.StringValue(in.MacAddress)
+
+	data.IPv4Address = types.StringValue("")
+	data.IPv6Address = types.StringValue("")
+	for _, address := range in.AssignedAddresses {
+		if ip := net.ParseIP(address); ip != nil {
+			if ip.To4() != nil && data.IPv4Address.ValueString() == "" {
+				data.IPv4Address = types.StringValue(ip.String())
+			}
+			if ip.To4() == nil && data.IPv6Address.ValueString() == "" {
+				data.IPv6Address = types.StringValue(ip.String())
+			}
+		}
+	}
+
+	data.AssignedAddresses, newDiags = types.ListValueFrom(ctx, types.StringType, in.AssignedAddresses)
+	diags.Append(newDiags...)
+	data.DiscoveredAddresses, newDiags = types.ListValueFrom(ctx, types.StringType, in.DiscoveredAddresses)
+	diags.Append(newDiags...)
+
+	return diags
+}
+
+// findNetworkInterface returns the network interface with the id, or with the label when no id is known yet
+func findNetworkInterface(networkInterfaces []client.NetworkInterface, id string, label string) *client.NetworkInterface {
+	for i, networkInterface := range networkInterfaces {
+		if (id != "" && networkInterface.Id == id) || (id == "" && networkInterface.Label == label) {
+			return &networkInterfaces[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package virtual_server_network_interface
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+	"strings"
+)
+
+const ResourceType = "previder_virtual_server_network_interface"
+const logSubsystem = "previder.virtual_server_network_interface"
+
+var stateUpgrades []util.StateUpgrade
+
+// maxNetworkInterfaces is the same limit as the network_interfaces map of previder_virtual_server
+const maxNetworkInterfaces = 8
+
+var _ resource.Resource = (*resourceImpl)(nil)
+var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
+var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
+
+type resourceImpl struct {
+	client   *client.PreviderClient
+	customer string
+}
+
+func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func NewResource() resource.Resource {
+	return &resourceImpl{}
+}
+
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
+	resp.Schema.Attributes = map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "ID of the network interface",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"virtual_server": schema.StringAttribute{
+			MarkdownDescription: "ID of the virtual server the network interface is added to",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"label": schema.StringAttribute{
+			MarkdownDescription: "Label of the network interface, must be unique within the virtual server",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"network": schema.StringAttribute{
+			MarkdownDescription: "ObjectId or name of the virtual network",
+			Required:            true,
+		},
+		"connected": schema.BoolAttribute{
+			Optional: true,
+			Computed: true,
+			Default:  booldefault.StaticBool(true),
+		},
+		"type": schema.StringAttribute{
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"mac_address": schema.StringAttribute{
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"ipv4_address": schema.StringAttribute{
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"ipv6_address": schema.StringAttribute{
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"assigned_addresses": schema.ListAttribute{
+			ElementType: types.StringType,
+			Computed:    true,
+			PlanModifiers: []planmodifier.List{
+				listplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"discovered_addresses": schema.ListAttribute{
+			ElementType: types.StringType,
+			Computed:    true,
+			PlanModifiers: []planmodifier.List{
+				listplanmodifier.UseStateForUnknown(),
+			},
+		},
+	}
+}
+
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var plan, data resourceData
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := plan.VirtualServer.ValueString()
+	label := plan.Label.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Adding network interface to virtual server", map[string]any{util.LogFieldId: virtualServerId, "label": label})
+	vm, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for _, networkInterface := range update.NetworkInterfaces {
+			if networkInterface.Label == label {
+				return fmt.Errorf("virtual server already has a network interface with label %s", label)
+			}
+		}
+		if len(update.NetworkInterfaces) >= maxNetworkInterfaces {
+			return fmt.Errorf("virtual server already has %d network interfaces", len(update.NetworkInterfaces))
+		}
+		update.NetworkInterfaces = append(update.NetworkInterfaces, util.NetworkInterfaceUpdate{
+			NetworkInterfaceUpdate: client.NetworkInterfaceUpdate{
+				Network:   plan.Network.ValueString(),
+				Connected: plan.Connected.ValueBool(),
+				Label:     label,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error while adding network interface", fmt.Sprintf("Error while adding network interface %s to Virtual Server (%s): %s", label, virtualServerId, err))
+		return
+	}
+
+	networkInterface := findNetworkInterface(vm.NetworkInterfaces, "", label)
+	if networkInterface == nil {
+		resp.Diagnostics.AddError("Network interface not found after adding", fmt.Sprintf("Network interface %s is not found on Virtual Server (%s)", label, virtualServerId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(ctx, &data, virtualServerId, networkInterface, &plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, data resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, state.VirtualServer.ValueString())
+	if err != nil {
+		if apiError, ok := err.(*client.ApiError); ok && apiError.Code == 404 {
+			tflog.SubsystemWarn(ctx, logSubsystem, "Virtual server of the network interface is deleted outside of Terraform", map[string]any{util.LogFieldId: state.VirtualServer.ValueString(), "network_interface_id": state.Id.ValueString()})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error while fetching Virtual Server (%s): %s", state.VirtualServer.ValueString(), err))
+		return
+	}
+
+	networkInterface := findNetworkInterface(vm.NetworkInterfaces, state.Id.ValueString(), state.Label.ValueString())
+	if networkInterface == nil {
+		tflog.SubsystemWarn(ctx, logSubsystem, "Network interface is removed outside of Terraform", map[string]any{util.LogFieldId: state.VirtualServer.ValueString(), "network_interface_id": state.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(ctx, &data, state.VirtualServer.ValueString(), networkInterface, &state)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, plan, data resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := state.VirtualServer.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Updating network interface of virtual server", map[string]any{util.LogFieldId: virtualServerId, "network_interface_id": state.Id.ValueString()})
+	vm, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for i, networkInterface := range update.NetworkInterfaces {
+			if networkInterface.Id == state.Id.ValueString() {
+				update.NetworkInterfaces[i].Network = plan.Network.ValueString()
+				update.NetworkInterfaces[i].Connected = plan.Connected.ValueBool()
+				return nil
+			}
+		}
+		return fmt.Errorf("network interface %s is not part of the virtual server", state.Id.ValueString())
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error while updating network interface", fmt.Sprintf("Error while updating network interface %s of Virtual Server (%s): %s", state.Label.ValueString(), virtualServerId, err))
+		return
+	}
+
+	networkInterface := findNetworkInterface(vm.NetworkInterfaces, state.Id.ValueString(), state.Label.ValueString())
+	if networkInterface == nil {
+		resp.Diagnostics.AddError("Network interface not found after updating", fmt.Sprintf("Network interface %s is not found on Virtual Server (%s)", state.Label.ValueString(), virtualServerId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(ctx, &data, virtualServerId, networkInterface, &plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := state.VirtualServer.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Removing network interface from virtual server", map[string]any{util.LogFieldId: virtualServerId, "network_interface_id": state.Id.ValueString()})
+	_, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		for i, networkInterface := range update.NetworkInterfaces {
+			if networkInterface.Id == state.Id.ValueString() {
+				update.NetworkInterfaces[i].Deleted = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Network interface not removed", fmt.Sprintf("Error while removing network interface %s from Virtual Server (%s): %s", state.Label.ValueString(), virtualServerId, err))
+	}
+}
+
+func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var data resourceData
+
+	virtualServerId, networkInterfaceId, found := strings.Cut(req.ID, "/")
+	if !found || virtualServerId == "" || networkInterfaceId == "" {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Import ID %q must be <virtual server id>/<network interface id or label>", req.ID))
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, virtualServerId)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while importing network interface of Virtual Server (%s): %s", virtualServerId, err))
+		return
+	}
+
+	networkInterface := findNetworkInterface(vm.NetworkInterfaces, networkInterfaceId, "")
+	if networkInterface == nil {
+		networkInterface = findNetworkInterface(vm.NetworkInterfaces, "", networkInterfaceId)
+	}
+	if networkInterface == nil {
+		resp.Diagnostics.AddError("Network interface not found", fmt.Sprintf("Virtual Server (%s) has no network interface with id or label %s", virtualServerId, networkInterfaceId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(ctx, &data, vm.Id, networkInterface, nil)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,25 @@
+package virtual_server_template
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+type resourceData struct {
+	Id            types.String `tfsdk:"id"`
+	VirtualServer types.String `tfsdk:"virtual_server"`
+	Name          types.String `tfsdk:"name"`
+	GuestId       types.String `tfsdk:"guest_id"`
+}
+
+func populateResourceData(data *resourceData, in *util.VirtualMachineExt) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	data.Id = types.StringValue(in.Id)
+	data.VirtualServer = types.StringValue(in.Id)
+	data.Name = types.StringValue(in.Name)
+	data.GuestId = types.StringValue(in.GuestId)
+
+	return diags
+}
@@ -0,0 +1,222 @@
+package virtual_server_template
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
+	"github.com/previder/terraform-provider-previder/internal/util"
+)
+
+const ResourceType = "previder_virtual_server_template"
+const logSubsystem = "previder.virtual_server_template"
+
+var stateUpgrades []util.StateUpgrade
+
+var _ resource.Resource = (*resourceImpl)(nil)
+var _ resource.ResourceWithConfigure = (*resourceImpl)(nil)
+var _ resource.ResourceWithImportState = (*resourceImpl)(nil)
+var _ resource.ResourceWithUpgradeState = (*resourceImpl)(nil)
+
+type resourceImpl struct {
+	client   *client.PreviderClient
+	customer string
+}
+
+func (r *resourceImpl) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = ResourceType
+}
+
+func NewResource() resource.Resource {
+	return &resourceImpl{}
+}
+
+func (r *resourceImpl) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	var newDiags diag.Diagnostics
+	r.client, r.customer, newDiags = util.ConfigureClient(ctx, req.ProviderData)
+	resp.Diagnostics.Append(newDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (r *resourceImpl) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema.Version = util.SchemaVersion(stateUpgrades)
+	resp.Schema.MarkdownDescription = "Marks a powered off virtual server as template, the template is cloned by using its ID as `source` of a virtual server"
+	resp.Schema.Attributes = map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "ID of the template, which is the ID of the virtual server",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"virtual_server": schema.StringAttribute{
+			MarkdownDescription: "ID of the powered off virtual server to mark as template",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Name of the virtual server",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"guest_id": schema.StringAttribute{
+			MarkdownDescription: "Guest operating system of the virtual server",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+	}
+}
+
+func (r *resourceImpl) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return util.StateUpgraders(stateUpgrades)
+}
+
+func (r *resourceImpl) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var plan, data resourceData
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := plan.VirtualServer.ValueString()
+
+	vm, err := util.GetVirtualMachine(r.client, virtualServerId)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while fetching Virtual Server (%s): %s", virtualServerId, err))
+		return
+	}
+	if vm.State != client.VmStatePoweredOff {
+		resp.Diagnostics.AddError("Virtual server not powered off", fmt.Sprintf("Virtual Server (%s) is %s, only a powered off virtual server can be marked as template. Set power_state to off on the virtual server first", virtualServerId, vm.State))
+		return
+	}
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Marking virtual server as template", map[string]any{util.LogFieldId: virtualServerId})
+	vm, err = util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		update.MarkedAsTemplate = true
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error while marking as template", fmt.Sprintf("Virtual Server (%s) is not marked as template: %s", virtualServerId, err))
+		return
+	}
+	if !vm.MarkedAsTemplate {
+		resp.Diagnostics.AddError("Error while marking as template", fmt.Sprintf("Virtual Server (%s) is updated, but the API did not mark it as template", virtualServerId))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, vm)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state, data resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, state.VirtualServer.ValueString())
+	if err != nil {
+		if apiError, ok := err.(*client.ApiError); ok && apiError.Code == 404 {
+			tflog.SubsystemWarn(ctx, logSubsystem, "Virtual server of the template is deleted outside of Terraform", map[string]any{util.LogFieldId: state.VirtualServer.ValueString()})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Invalid ID", fmt.Sprintf("Error while fetching Virtual Server (%s): %s", state.VirtualServer.ValueString(), err))
+		return
+	}
+
+	if !vm.MarkedAsTemplate {
+		tflog.SubsystemWarn(ctx, logSubsystem, "Virtual server is no longer a template", map[string]any{util.LogFieldId: state.VirtualServer.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, vm)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update only refreshes the computed attributes, changing the virtual server replaces the template
+func (r *resourceImpl) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var plan, data resourceData
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, plan.VirtualServer.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while fetching Virtual Server (%s): %s", plan.VirtualServer.ValueString(), err))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, vm)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceImpl) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var state resourceData
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	virtualServerId := state.VirtualServer.ValueString()
+
+	tflog.SubsystemInfo(ctx, logSubsystem, "Unmarking virtual server as template", map[string]any{util.LogFieldId: virtualServerId})
+	_, err := util.ChangeVirtualMachine(ctx, r.client, virtualServerId, func(update *util.VirtualMachineUpdate) error {
+		update.MarkedAsTemplate = false
+		return nil
+	})
+	if err != nil {
+		if apiError, ok := err.(*client.ApiError); ok && apiError.Code == 404 {
+			tflog.SubsystemWarn(ctx, logSubsystem, "Virtual server of the template is deleted outside of Terraform", map[string]any{util.LogFieldId: virtualServerId})
+			return
+		}
+		resp.Diagnostics.AddError("Template not removed", fmt.Sprintf("Error while unmarking Virtual Server (%s) as template: %s", virtualServerId, err))
+	}
+}
+
+func (r *resourceImpl) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = util.NewLogContext(ctx, logSubsystem, r.customer)
+	var data resourceData
+
+	if !util.IsValidObjectId(req.ID) {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Import ID %q must be the ObjectId of the virtual server", req.ID))
+		return
+	}
+
+	vm, err := util.GetVirtualMachine(r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Virtual server not found", fmt.Sprintf("Error while importing template (%s): %s", req.ID, err))
+		return
+	}
+	if !vm.MarkedAsTemplate {
+		resp.Diagnostics.AddError("Virtual server is not a template", fmt.Sprintf("Virtual Server (%s) is not marked as template", req.ID))
+		return
+	}
+
+	resp.Diagnostics.Append(populateResourceData(&data, vm)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -2,20 +2,39 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
+	"github.com/previder/terraform-provider-previder/internal/export"
 	"github.com/previder/terraform-provider-previder/previder"
 	"log"
+	"os"
 )
 
 func main() {
 	ctx := context.Background()
 
+	// Subcommands are only available when the binary is started by hand, Terraform never passes arguments
+	if len(os.Args) > 1 && os.Args[1] == export.Command {
+		err := export.Run(ctx, os.Args[2:], os.Stderr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	providerFactory, err := previder.GetMuxedProvider(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "Start the provider with support for debuggers like delve, prints the TF_REATTACH_PROVIDERS value to use")
+	flag.Parse()
+
 	var serveOpts []tf6server.ServeOpt
+	if debug {
+		serveOpts = append(serveOpts, tf6server.WithManagedDebug())
+	}
 
 	err = tf6server.Serve(
 		"registry.terraform.io/previder/previder",
@@ -3,20 +3,28 @@ package previder
 import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/list"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/previder/previder-go-sdk/client"
 	"github.com/previder/terraform-provider-previder/internal/kubernetes_cluster"
 	"github.com/previder/terraform-provider-previder/internal/staas_environment"
+	"github.com/previder/terraform-provider-previder/internal/util"
 	"github.com/previder/terraform-provider-previder/internal/virtual_firewall"
 	"github.com/previder/terraform-provider-previder/internal/virtual_network"
 	"github.com/previder/terraform-provider-previder/internal/virtual_server"
+	"github.com/previder/terraform-provider-previder/internal/virtual_server_disk"
+	"github.com/previder/terraform-provider-previder/internal/virtual_server_network_interface"
+	"github.com/previder/terraform-provider-previder/internal/virtual_server_template"
 )
 
 type PreviderProvider struct{}
 
 var _ provider.Provider = &PreviderProvider{}
+var _ provider.ProviderWithListResources = &PreviderProvider{}
 var version = provider.MetadataResponse{Version: "not built yet"}
 
 func NewPreviderProvider() provider.Provider {
@@ -75,8 +83,18 @@ func (p *PreviderProvider) Configure(ctx context.Context, req provider.Configure
 		resp.Diagnostics.AddError("Error initialing Previder Provider", err.Error())
 		return
 	}
-	resp.DataSourceData = baseClient
-	resp.ResourceData = baseClient
+	providerData := &util.ProviderData{
+		Client:     baseClient,
+		CustomerId: config.CustomerId.ValueString(),
+		CustomerClient: func(customerId string) (*client.PreviderClient, error) {
+			customerConfig := config
+			customerConfig.CustomerId = types.StringValue(customerId)
+			return customerConfig.Client()
+		},
+	}
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
+	resp.ListResourceData = providerData
 
 	tflog.Info(ctx, "Previder Client configured", map[string]any{"url": config.Url.ValueString(), "customer": config.CustomerId.ValueString()})
 	tflog.Info(ctx, "terraform-provider-previder info", map[string]any{"version": version.Version})
@@ -99,9 +117,27 @@ func (p *PreviderProvider) DataSources(_ context.Context) []func() datasource.Da
 func (p *PreviderProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		virtual_server.NewResource,
+		virtual_server_disk.NewResource,
+		virtual_server_network_interface.NewResource,
+		virtual_server_template.NewResource,
 		virtual_network.NewResource,
 		virtual_firewall.NewResource,
 		kubernetes_cluster.NewResource,
 		staas_environment.NewResource,
 	}
 }
+
+// ListResources returns a slice of functions to instantiate each ListResource
+// implementation.
+//
+// The list resource type name is determined by the ListResource implementing
+// the Metadata method and has to match the type name of the Resource.
+func (p *PreviderProvider) ListResources(_ context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		virtual_server.NewListResource,
+		virtual_network.NewListResource,
+		virtual_firewall.NewListResource,
+		kubernetes_cluster.NewListResource,
+		staas_environment.NewListResource,
+	}
+}